@@ -0,0 +1,151 @@
+package clean
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	localToolPath string
+	options       prm.CleanOptions
+	olderThan     string
+	dryRun        bool
+	force         bool
+	prmApi        *prm.Prm
+)
+
+func CreateCommand(parent *prm.Prm) *cobra.Command {
+	prmApi = parent
+
+	tmp := &cobra.Command{
+		Use:   "clean",
+		Short: "Removes the images, containers, cache and logs PRM leaves behind",
+		Long: `Removes the images built for tools and the Dockerfiles generated to build them, containers left
+behind by earlier runs, the contents of the cache directory and the validation logs in the codedir.
+
+Without any of --images, --containers, --cache or --logs everything is removed, and --unused on its
+own only removes images. What will be removed, and the space that frees, is listed and confirmed
+before anything is removed.`,
+		Args:    cobra.NoArgs,
+		PreRunE: preExecute,
+		RunE:    execute,
+	}
+
+	tmp.Flags().SortFlags = false
+	tmp.Flags().BoolVar(&options.Images, "images", false, "remove tool images and the Dockerfiles generated to build them")
+	tmp.Flags().BoolVar(&options.Containers, "containers", false, "remove stopped tool containers")
+	tmp.Flags().BoolVar(&options.Cache, "cache", false, "remove the contents of the cache directory")
+	tmp.Flags().BoolVar(&options.Logs, "logs", false, "remove the validation logs and reports in the codedir")
+	tmp.Flags().StringVar(&olderThan, "older-than", "", "only remove items last modified longer ago than this, e.g. 30d or 12h")
+	tmp.Flags().BoolVar(&options.Unused, "unused", false, "only remove images of tool versions that are no longer installed; on its own, selects images only")
+	tmp.Flags().BoolVar(&dryRun, "dry-run", false, "list what would be removed without removing it")
+	tmp.Flags().BoolVarP(&force, "force", "f", false, "remove without asking for confirmation")
+
+	tmp.Flags().StringVar(&localToolPath, "toolpath", "", "location of installed tools")
+
+	tmp.Flags().StringVar(&prmApi.CodeDir, "codedir", "", "location of code whose validation logs are removed")
+	err := viper.BindPFlag("codedir", tmp.Flags().Lookup("codedir"))
+	cobra.CheckErr(err)
+
+	tmp.Flags().StringVar(&prmApi.CacheDir, "cachedir", "", "location of cache used by PRM")
+	err = viper.BindPFlag("cachedir", tmp.Flags().Lookup("cachedir"))
+	cobra.CheckErr(err)
+
+	return tmp
+}
+
+func preExecute(cmd *cobra.Command, args []string) error {
+	if localToolPath == "" {
+		localToolPath = prmApi.RunningConfig.ToolPath
+	}
+	options.ToolPath = localToolPath
+
+	var err error
+	options.OlderThan, err = parseAge(olderThan)
+	if err != nil {
+		return err
+	}
+
+	if prmApi.CodeDir == "" {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("unable to set working directory as default codedir: %s", err)
+		}
+		prmApi.CodeDir = workingDirectory
+	}
+
+	if prmApi.CacheDir == "" {
+		usr, _ := user.Current()
+		prmApi.CacheDir = filepath.Join(usr.HomeDir, ".pdk/prm/cache")
+	}
+
+	if prmApi.Backend == nil {
+		prmApi.Backend = prmApi.NewBackend(false)
+	}
+	return nil
+}
+
+// Parses a duration, which may also be given in days, e.g. "30d"
+func parseAge(age string) (time.Duration, error) {
+	if age == "" {
+		return 0, nil
+	}
+	if strings.HasSuffix(age, "d") {
+		count, err := strconv.Atoi(strings.TrimSuffix(age, "d"))
+		if err != nil || count < 0 {
+			return 0, fmt.Errorf("invalid --older-than '%s', expected a duration such as 30d or 12h", age)
+		}
+		return time.Duration(count) * 24 * time.Hour, nil
+	}
+	duration, err := time.ParseDuration(age)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("invalid --older-than '%s', expected a duration such as 30d or 12h", age)
+	}
+	return duration, nil
+}
+
+func execute(cmd *cobra.Command, args []string) error {
+	items, err := prmApi.FindCleanItems(options)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		log.Info().Msg("Nothing to clean")
+		return nil
+	}
+
+	fmt.Fprint(cmd.OutOrStdout(), prm.FormatCleanItems(items))
+	if dryRun {
+		return nil
+	}
+	if !force && !confirm(cmd) {
+		log.Info().Msg("Nothing removed")
+		return nil
+	}
+
+	freed, err := prmApi.Clean(items)
+	log.Info().Msgf("Freed %s", prm.FormatSize(freed))
+	return err
+}
+
+// Asks whether to remove the listed items; anything but yes, including no answer, declines
+func confirm(cmd *cobra.Command) bool {
+	fmt.Fprint(cmd.OutOrStdout(), "Remove these items? [y/N] ")
+	answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
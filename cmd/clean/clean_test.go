@@ -0,0 +1,112 @@
+package clean_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/puppetlabs/prm/cmd/clean"
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CleanCommand(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		input          string
+		expectedOutput []string
+		missingOutput  []string
+		removedFiles   []string
+		keptFiles      []string
+		expectError    bool
+	}{
+		{
+			name:           "Should list but keep everything on a dry run",
+			args:           []string{"--dry-run"},
+			expectedOutput: []string{"cache/validate/abc123.json", "code/.prm-validate/lint.log", "2 items, 14B in total"},
+			keptFiles:      []string{"cache/validate/abc123.json", "code/.prm-validate/lint.log"},
+		},
+		{
+			name:           "Should only remove the selected items",
+			args:           []string{"--logs"},
+			input:          "y\n",
+			expectedOutput: []string{"code/.prm-validate/lint.log", "1 item, 7B in total", "Remove these items? [y/N]"},
+			removedFiles:   []string{"code/.prm-validate/lint.log"},
+			keptFiles:      []string{"cache/validate/abc123.json"},
+		},
+		{
+			name:           "Should keep everything when removal is not confirmed",
+			args:           []string{"--logs"},
+			input:          "n\n",
+			expectedOutput: []string{"code/.prm-validate/lint.log", "Remove these items? [y/N]"},
+			keptFiles:      []string{"cache/validate/abc123.json", "code/.prm-validate/lint.log"},
+		},
+		{
+			name:           "Should keep everything without an answer",
+			args:           []string{"--logs"},
+			expectedOutput: []string{"Remove these items? [y/N]"},
+			keptFiles:      []string{"cache/validate/abc123.json", "code/.prm-validate/lint.log"},
+		},
+		{
+			name:          "Should remove without confirmation when forced",
+			args:          []string{"--cache", "--force"},
+			missingOutput: []string{"Remove these items?"},
+			removedFiles:  []string{"cache/validate/abc123.json"},
+			keptFiles:     []string{"code/.prm-validate/lint.log"},
+		},
+		{
+			name:      "Should keep items newer than --older-than",
+			args:      []string{"--older-than", "7d"},
+			keptFiles: []string{"cache/validate/abc123.json", "code/.prm-validate/lint.log"},
+		},
+		{
+			name:        "Should error for an invalid --older-than",
+			args:        []string{"--older-than", "a week"},
+			expectError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			afs := &afero.Afero{Fs: fs}
+			for _, file := range []string{"cache/validate/abc123.json", "code/.prm-validate/lint.log"} {
+				afs.WriteFile(file, []byte("content"), 0644) //nolint:errcheck
+			}
+
+			prmObj := &prm.Prm{
+				AFS:           afs,
+				IOFS:          &afero.IOFS{Fs: fs},
+				RunningConfig: prm.Config{ToolPath: "path/to/tools", Backend: prm.LOCAL},
+			}
+			cleanCmd := clean.CreateCommand(prmObj)
+			b := bytes.NewBufferString("")
+			cleanCmd.SetOutput(b)
+			cleanCmd.SetIn(bytes.NewBufferString(tt.input))
+			cleanCmd.SetArgs(append([]string{"--codedir", "code", "--cachedir", "cache"}, tt.args...))
+
+			err := cleanCmd.Execute()
+			if (err != nil) != tt.expectError {
+				t.Errorf("Unexpected error: %s", err)
+				return
+			}
+
+			out, _ := ioutil.ReadAll(b)
+			for _, expected := range tt.expectedOutput {
+				assert.Contains(t, string(out), expected)
+			}
+			for _, missing := range tt.missingOutput {
+				assert.NotContains(t, string(out), missing)
+			}
+			for _, file := range tt.removedFiles {
+				exists, _ := afs.Exists(file)
+				assert.False(t, exists, "%s was not removed", file)
+			}
+			for _, file := range tt.keptFiles {
+				exists, _ := afs.Exists(file)
+				assert.True(t, exists, "%s was removed", file)
+			}
+		})
+	}
+}
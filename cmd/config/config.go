@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/spf13/cobra"
+)
+
+var (
+	showOrigin bool
+	prmApi     *prm.Prm
+)
+
+func CreateCommand(parent *prm.Prm) *cobra.Command {
+	prmApi = parent
+
+	tmp := &cobra.Command{
+		Use:   "config",
+		Short: "Displays the effective PRM configuration",
+		Long:  "Displays the effective PRM configuration, layering any project .prm.yaml files over the global config",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.HelpFunc()(cmd, args)
+		},
+	}
+
+	tmp.AddCommand(createShowCommand())
+
+	return tmp
+}
+
+func createShowCommand() *cobra.Command {
+	tmp := &cobra.Command{
+		Use:   "show",
+		Short: "Shows each effective configuration setting",
+		Long: `Shows each effective configuration setting.
+
+Settings are resolved with the following precedence, highest first:
+  - command line flags
+  - environment variables
+  - .prm.yaml files in the codedir and its parents up to the git root (closest first)
+  - the global config file (default is $HOME/.config/.prm.yaml)
+  - defaults`,
+		RunE: execute,
+	}
+
+	tmp.Flags().SortFlags = false
+	tmp.Flags().BoolVar(&showOrigin, "origin", false, "show the file or environment variable each setting came from")
+	tmp.Flags().StringVar(&prmApi.CodeDir, "codedir", "", "location of code whose project config is shown")
+
+	return tmp
+}
+
+func execute(cmd *cobra.Command, args []string) error {
+	settings, err := prmApi.GetConfigSettings()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(cmd.OutOrStdout(), formatSettings(settings, showOrigin))
+	return nil
+}
+
+func formatSettings(settings []prm.ConfigSetting, withOrigin bool) string {
+	stringBuilder := &strings.Builder{}
+	table := tablewriter.NewWriter(stringBuilder)
+	headers := []string{"Setting", "Value"}
+	if withOrigin {
+		headers = append(headers, "Origin")
+	}
+	table.SetHeader(headers)
+	table.SetBorder(false)
+	for _, setting := range settings {
+		row := []string{setting.Key, setting.Value}
+		if withOrigin {
+			row = append(row, setting.Origin)
+		}
+		table.Append(row)
+	}
+	table.Render()
+	return stringBuilder.String()
+}
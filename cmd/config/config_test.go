@@ -0,0 +1,58 @@
+package config_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/puppetlabs/prm/cmd/config"
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ConfigCommand(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		expectedOutput []string
+		expectError    bool
+	}{
+		{
+			name:           "Should display help when no subcommand passed to 'config'",
+			args:           []string{""},
+			expectedOutput: []string{"Displays the effective PRM configuration"},
+		},
+		{
+			name:           "Should show each setting",
+			args:           []string{"show"},
+			expectedOutput: []string{"SETTING", "VALUE", prm.PuppetVerCfgKey, prm.BackendCfgKey, prm.ToolPathCfgKey},
+		},
+		{
+			name:           "Should show where each setting came from",
+			args:           []string{"show", "--origin"},
+			expectedOutput: []string{"ORIGIN", prm.OriginDefault},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prmObj := &prm.Prm{AFS: &afero.Afero{Fs: afero.NewMemMapFs()}}
+			prmObj.GenerateDefaultCfg()
+			configCmd := config.CreateCommand(prmObj)
+			b := bytes.NewBufferString("")
+			configCmd.SetOutput(b)
+			configCmd.SetArgs(tt.args)
+
+			err := configCmd.Execute()
+			if (err != nil) != tt.expectError {
+				t.Errorf("Unexpected error: %s", err)
+				return
+			}
+
+			out, _ := ioutil.ReadAll(b)
+			for _, expected := range tt.expectedOutput {
+				assert.Contains(t, string(out), expected)
+			}
+		})
+	}
+}
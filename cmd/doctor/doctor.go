@@ -0,0 +1,94 @@
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	localToolPath string
+	format        string
+	prmApi        *prm.Prm
+)
+
+func CreateCommand(parent *prm.Prm) *cobra.Command {
+	prmApi = parent
+
+	tmp := &cobra.Command{
+		Use:   "doctor",
+		Short: "Checks the environment PRM runs tools in",
+		Long: `Checks that the backend is available, the toolpath exists and its tools parse, every tool in validate.yml
+is installed, the codedir and cachedir are writable, the Puppet version has an image and there is disk space to
+spare. Each check passes, warns or fails, with a hint on how to fix it.`,
+		Args:    cobra.NoArgs,
+		PreRunE: preExecute,
+		RunE:    execute,
+	}
+
+	tmp.Flags().SortFlags = false
+	tmp.Flags().StringVarP(&format, "format", "f", "table", "display output in table or json format")
+	err := tmp.RegisterFlagCompletionFunc("format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"table", "json"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	cobra.CheckErr(err)
+
+	tmp.Flags().StringVar(&localToolPath, "toolpath", "", "location of installed tools")
+
+	tmp.Flags().StringVar(&prmApi.CodeDir, "codedir", "", "location of code to check")
+	err = viper.BindPFlag("codedir", tmp.Flags().Lookup("codedir"))
+	cobra.CheckErr(err)
+
+	tmp.Flags().StringVar(&prmApi.CacheDir, "cachedir", "", "location of cache used by PRM")
+	err = viper.BindPFlag("cachedir", tmp.Flags().Lookup("cachedir"))
+	cobra.CheckErr(err)
+
+	return tmp
+}
+
+func preExecute(cmd *cobra.Command, args []string) error {
+	if format != "table" && format != "json" {
+		return fmt.Errorf("the --format flag must be set to either [table|json]")
+	}
+
+	if localToolPath == "" {
+		localToolPath = prmApi.RunningConfig.ToolPath
+	}
+
+	if prmApi.CodeDir == "" {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("unable to set working directory as default codedir: %s", err)
+		}
+		prmApi.CodeDir = workingDirectory
+	}
+
+	if prmApi.CacheDir == "" {
+		usr, _ := user.Current()
+		prmApi.CacheDir = filepath.Join(usr.HomeDir, ".pdk/prm/cache")
+	}
+
+	if prmApi.Backend == nil {
+		prmApi.Backend = prmApi.NewBackend(false)
+	}
+	return nil
+}
+
+func execute(cmd *cobra.Command, args []string) error {
+	checks := prmApi.Doctor(localToolPath)
+	output, err := prm.FormatDoctorChecks(checks, format)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(cmd.OutOrStdout(), output)
+
+	if failures := prm.DoctorFailures(checks); failures > 0 {
+		return fmt.Errorf("%d of %d checks failed", failures, len(checks))
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package doctor_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/puppetlabs/prm/cmd/doctor"
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DoctorCommand(t *testing.T) {
+	tests := []struct {
+		name           string
+		args           []string
+		expectedOutput []string
+		expectedJson   bool
+		expectedErrMsg string
+	}{
+		{
+			name:           "Should list the checks as a table by default",
+			expectedOutput: []string{"[pass] Puppet version: 7.15.0; the local backend runs tools with the host's Puppet", "[fail] Tool path:"},
+			expectedErrMsg: "checks failed",
+		},
+		{
+			name:           "Should list the checks as a table",
+			args:           []string{"--format", "table"},
+			expectedOutput: []string{"[pass] Puppet version:"},
+			expectedErrMsg: "checks failed",
+		},
+		{
+			name:           "Should list the checks as json",
+			args:           []string{"--format", "json"},
+			expectedJson:   true,
+			expectedErrMsg: "checks failed",
+		},
+		{
+			name:           "Should error for an invalid format",
+			args:           []string{"--format", "human"},
+			expectedErrMsg: "the --format flag must be set to either [table|json]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			afs := &afero.Afero{Fs: fs}
+			afs.MkdirAll("code", 0750)  //nolint:errcheck
+			afs.MkdirAll("cache", 0750) //nolint:errcheck
+
+			prmObj := &prm.Prm{
+				AFS:           afs,
+				IOFS:          &afero.IOFS{Fs: fs},
+				RunningConfig: prm.Config{ToolPath: "path/to/tools", Backend: prm.LOCAL, PuppetVersion: semver.MustParse("7.15.0")},
+			}
+			doctorCmd := doctor.CreateCommand(prmObj)
+			b := bytes.NewBufferString("")
+			doctorCmd.SetOutput(b)
+			// Keep the output to what the command prints, so json output can be parsed
+			doctorCmd.SilenceErrors = true
+			doctorCmd.SilenceUsage = true
+			doctorCmd.SetArgs(append([]string{"--codedir", "code", "--cachedir", "cache"}, tt.args...))
+
+			err := doctorCmd.Execute()
+			if tt.expectedErrMsg != "" {
+				assert.ErrorContains(t, err, tt.expectedErrMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			out, _ := ioutil.ReadAll(b)
+			for _, expected := range tt.expectedOutput {
+				assert.Contains(t, string(out), expected)
+			}
+			if tt.expectedJson {
+				var checks []prm.DoctorCheck
+				assert.NoError(t, json.Unmarshal(out, &checks))
+				assert.NotEmpty(t, checks)
+			}
+		})
+	}
+}
@@ -27,6 +27,7 @@ var (
 	toolArgs    string
 	alwaysBuild bool
 	toolTimeout int
+	frozen      bool
 )
 
 func CreateCommand(parent *prm.Prm) *cobra.Command {
@@ -34,7 +35,7 @@ func CreateCommand(parent *prm.Prm) *cobra.Command {
 	prmApi = parent
 
 	tmp := &cobra.Command{
-		Use:               "exec <tool> [overrides|flags]",
+		Use:               "exec <author/id[@version]> [overrides|flags]",
 		Short:             "Executes a given tool against some Puppet Content",
 		Long:              `Executes a given tool against some Puppet Content`,
 		Args:              validateArgCount,
@@ -79,6 +80,8 @@ func CreateCommand(parent *prm.Prm) *cobra.Command {
 	err = viper.BindPFlag("alwaysBuild", tmp.Flags().Lookup("alwaysBuild"))
 	cobra.CheckErr(err)
 
+	tmp.Flags().BoolVar(&frozen, "frozen", false, "Fail, rather than warn, when the tool, its image or the Puppet version differ from prm.lock")
+
 	tmp.Flags().IntVar(&toolTimeout, "toolTimeout", 1800, "Time in seconds to wait for a response before exiting; defaults to 1800 (i.e. 30 minutes)")
 	err = viper.BindPFlag("toolTimeout", tmp.Flags().Lookup("toolTimeout"))
 	cobra.CheckErr(err)
@@ -91,12 +94,7 @@ func preExecute(cmd *cobra.Command, args []string) error {
 		localToolPath = prmApi.RunningConfig.ToolPath
 	}
 
-	switch prmApi.RunningConfig.Backend {
-	case prm.DOCKER:
-		prmApi.Backend = &prm.Docker{AFS: prmApi.AFS, IOFS: prmApi.IOFS, AlwaysBuild: alwaysBuild, ContextTimeout: prmApi.RunningConfig.Timeout}
-	default:
-		prmApi.Backend = &prm.Docker{AFS: prmApi.AFS, IOFS: prmApi.IOFS, AlwaysBuild: alwaysBuild, ContextTimeout: prmApi.RunningConfig.Timeout}
-	}
+	prmApi.Backend = prmApi.NewBackend(alwaysBuild)
 
 	if prmApi.CodeDir == "" {
 		workingDirectory, err := os.Getwd()
@@ -122,7 +120,8 @@ func preExecute(cmd *cobra.Command, args []string) error {
 
 func validateArgCount(cmd *cobra.Command, args []string) error {
 	if len(args) >= 1 {
-		if len(strings.Split(args[0], "/")) != 2 {
+		toolName, _, _ := strings.Cut(args[0], "@")
+		if len(strings.Split(toolName, "/")) != 2 {
 			return fmt.Errorf("Selected tool must be in AUTHOR/ID format")
 		}
 		selectedTool = args[0]
@@ -186,12 +185,23 @@ func execute(cmd *cobra.Command, args []string) error {
 
 	if selectedTool != "" {
 		// get the tool from the cache
-		cachedTool, ok := prmApi.IsToolAvailable(selectedTool)
-		if !ok {
-			return fmt.Errorf("Tool %s not found in cache", selectedTool)
+		cachedTool, err := prmApi.IsToolAvailable(selectedTool)
+		if err != nil {
+			return err
 		}
+		mismatches, err := prmApi.CheckLock("", []prm.ToolInfo{{Tool: cachedTool}})
+		if err != nil {
+			return err
+		}
+		if len(mismatches) > 0 {
+			if frozen {
+				return prm.LockError(mismatches)
+			}
+			log.Warn().Msg(prm.LockError(mismatches).Error())
+		}
+
 		// execute!
-		err := prmApi.Exec(cachedTool, additionalToolArgs)
+		err = prmApi.Exec(cmd.Context(), cachedTool, additionalToolArgs)
 		if err != nil {
 			return err
 		}
@@ -48,6 +48,20 @@ func TestCreateCommand(t *testing.T) {
 			out:     "",
 			wantErr: false,
 		},
+		{
+			name:    "executes without error for a pinned tool version",
+			args:    []string{"author/templateId@~1.2"},
+			f:       nullFunction,
+			out:     "",
+			wantErr: false,
+		},
+		{
+			name:    "executes with error when a pinned tool is provided in the wrong format",
+			args:    []string{"foo-bar@1.2.0"},
+			f:       nullFunction,
+			out:     "Selected tool must be in AUTHOR/ID format",
+			wantErr: true,
+		},
 		{
 			name:    "executes with error when tool provided in the wrong format",
 			args:    []string{"foo-bar"},
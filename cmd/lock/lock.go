@@ -0,0 +1,81 @@
+package lock
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var (
+	localToolPath string
+	alwaysBuild   bool
+	prmApi        *prm.Prm
+)
+
+func CreateCommand(parent *prm.Prm) *cobra.Command {
+	prmApi = parent
+
+	tmp := &cobra.Command{
+		Use:   "lock",
+		Short: "Locks the tools used to validate Puppet Content",
+		Long: `Resolves the tools in each validate.yml group and writes them to prm.lock in the codedir,
+along with the Puppet version and the IDs of the images built for them.
+
+'prm validate' and 'prm exec' then warn, or with --frozen fail, when the environment no longer matches the lock.`,
+		Args:    cobra.NoArgs,
+		PreRunE: preExecute,
+		RunE:    execute,
+	}
+
+	tmp.Flags().SortFlags = false
+
+	tmp.Flags().StringVar(&localToolPath, "toolpath", "", "location of installed tools")
+
+	tmp.Flags().StringVar(&prmApi.CodeDir, "codedir", "", "location of code whose validate.yml is locked")
+	err := viper.BindPFlag("codedir", tmp.Flags().Lookup("codedir"))
+	cobra.CheckErr(err)
+
+	tmp.Flags().BoolVarP(&alwaysBuild, "alwaysBuild", "a", false, "Rebuild the docker image for each tool, even if it already exists")
+	err = viper.BindPFlag("alwaysBuild", tmp.Flags().Lookup("alwaysBuild"))
+	cobra.CheckErr(err)
+
+	return tmp
+}
+
+func preExecute(cmd *cobra.Command, args []string) error {
+	if localToolPath == "" {
+		localToolPath = prmApi.RunningConfig.ToolPath
+	}
+
+	if prmApi.CodeDir == "" {
+		workingDirectory, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("unable to set working directory as default codedir: %s", err)
+		}
+		prmApi.CodeDir = workingDirectory
+	}
+
+	if prmApi.Backend == nil {
+		prmApi.Backend = prmApi.NewBackend(alwaysBuild)
+	}
+
+	return prmApi.List(localToolPath, "", false)
+}
+
+func execute(cmd *cobra.Command, args []string) error {
+	lock, err := prmApi.CreateLock()
+	if err != nil {
+		return err
+	}
+
+	lockFile, err := prmApi.WriteLock(lock)
+	if err != nil {
+		return err
+	}
+	log.Info().Msgf("Locked %d tool groups to %s", len(lock.Groups), lockFile)
+	return nil
+}
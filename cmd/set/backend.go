@@ -10,6 +10,8 @@ import (
 
 var SelectedBackend prm.BackendType
 
+var validBackends = []prm.BackendType{prm.DOCKER, prm.PODMAN, prm.LOCAL}
+
 func (sc *SetCommand) createSetBackendCommand() *cobra.Command {
 	tmp := &cobra.Command{
 		Use:       "backend <BACKEND>",
@@ -17,7 +19,7 @@ func (sc *SetCommand) createSetBackendCommand() *cobra.Command {
 		Long:      `Sets the backend exec environment to the specified type`,
 		PreRunE:   sc.setBackendPreRunE,
 		RunE:      sc.setBackendType,
-		ValidArgs: []string{string(prm.DOCKER)},
+		ValidArgs: backendNames(),
 	}
 
 	return tmp
@@ -25,23 +27,35 @@ func (sc *SetCommand) createSetBackendCommand() *cobra.Command {
 
 func (sc *SetCommand) setBackendPreRunE(cmd *cobra.Command, args []string) (err error) {
 	if len(args) > 1 {
-		return fmt.Errorf("too many args, please specify ONE of the following backend types after 'set backend':\n- %s", prm.DOCKER)
+		return fmt.Errorf("too many args, please specify ONE of the following backend types after 'set backend':\n%s", backendList())
 	}
 
 	if len(args) < 1 {
-		return fmt.Errorf("please specify specify one of the following backend types after 'set backend':\n- %s", prm.DOCKER)
+		return fmt.Errorf("please specify specify one of the following backend types after 'set backend':\n%s", backendList())
 	}
 
-	switch strings.ToLower(args[0]) {
-	case string(prm.DOCKER):
-		SelectedBackend = prm.DOCKER
-	default:
-		return fmt.Errorf("'%s' is not a valid backend type, please specify one of the following backend types:\n- %s", args[0], prm.DOCKER)
+	for _, backend := range validBackends {
+		if strings.ToLower(args[0]) == string(backend) {
+			SelectedBackend = backend
+			return nil
+		}
 	}
 
-	return nil
+	return fmt.Errorf("'%s' is not a valid backend type, please specify one of the following backend types:\n%s", args[0], backendList())
 }
 
 func (sc *SetCommand) setBackendType(cmd *cobra.Command, args []string) error {
 	return sc.Utils.SetAndWriteConfig(prm.BackendCfgKey, string(SelectedBackend))
 }
+
+func backendNames() []string {
+	var names []string
+	for _, backend := range validBackends {
+		names = append(names, string(backend))
+	}
+	return names
+}
+
+func backendList() string {
+	return "- " + strings.Join(backendNames(), "\n- ")
+}
@@ -85,6 +85,16 @@ func Test_SetBackendCommand(t *testing.T) {
 			args:               []string{"backend", "dOcKeR"},
 			expectedBackedType: prm.DOCKER,
 		},
+		{
+			name:               "Should handle valid backend selection (podman)",
+			args:               []string{"backend", "podman"},
+			expectedBackedType: prm.PODMAN,
+		},
+		{
+			name:               "Should handle valid backend selection (local)",
+			args:               []string{"backend", "local"},
+			expectedBackedType: prm.LOCAL,
+		},
 		{
 			name:           "Should error when too many args supplied to 'backend' sub cmd",
 			args:           []string{"backend", "foo", "bar"},
@@ -0,0 +1,160 @@
+package tool
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/puppetlabs/prm/internal/pkg/utils"
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	localToolPath string
+	format        string
+	allVersions   bool
+	removeImages  bool
+	prmApi        *prm.Prm
+)
+
+func CreateCommand(parent *prm.Prm) *cobra.Command {
+	prmApi = parent
+
+	tmp := &cobra.Command{
+		Use:   "tool",
+		Short: "Manages the tools installed in the toolpath",
+		Long:  "Lists and uninstalls the tools installed in the toolpath",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.HelpFunc()(cmd, args)
+		},
+	}
+
+	tmp.PersistentFlags().StringVar(&localToolPath, "toolpath", "", "location of installed tools")
+
+	tmp.AddCommand(createListCommand())
+	tmp.AddCommand(createUninstallCommand())
+
+	return tmp
+}
+
+func createListCommand() *cobra.Command {
+	tmp := &cobra.Command{
+		Use:   "list",
+		Short: "Lists the installed tools",
+		Long:  "Lists the newest installed version of each tool, or every installed version with --all-versions",
+		Args:  cobra.NoArgs,
+		RunE:  executeList,
+	}
+
+	tmp.Flags().SortFlags = false
+	tmp.Flags().BoolVar(&allVersions, "all-versions", false, "list every installed version of each tool")
+	tmp.Flags().StringVar(&format, "format", "table", "display output in human-readable or json format")
+	err := tmp.RegisterFlagCompletionFunc("format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return utils.Find([]string{"table", "json"}, toComplete), cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+	})
+	cobra.CheckErr(err)
+
+	return tmp
+}
+
+func createUninstallCommand() *cobra.Command {
+	tmp := &cobra.Command{
+		Use:   "uninstall <author/id[@version]>",
+		Short: "Uninstalls a tool from the toolpath",
+		Long:  "Uninstalls a tool from the toolpath. Without a version every installed version of the tool is removed",
+		Args:  validateUninstallArgs,
+		RunE:  executeUninstall,
+	}
+
+	tmp.Flags().BoolVar(&removeImages, "images", false, "also remove the images the backend built for the tool")
+
+	return tmp
+}
+
+func validateUninstallArgs(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("a single tool must be specified in AUTHOR/ID[@VERSION] format")
+	}
+	toolName, _, _ := strings.Cut(args[0], "@")
+	if len(strings.Split(toolName, "/")) != 2 {
+		return fmt.Errorf("Selected tool must be in AUTHOR/ID[@VERSION] format")
+	}
+	return nil
+}
+
+func toolPath() string {
+	if localToolPath == "" {
+		return prmApi.RunningConfig.ToolPath
+	}
+	return localToolPath
+}
+
+func executeList(cmd *cobra.Command, args []string) error {
+	if format != "table" && format != "json" {
+		return fmt.Errorf("the --format flag must be set to either [table|json]")
+	}
+
+	var output string
+	if allVersions {
+		tools, err := prmApi.ListAllVersions(toolPath())
+		if err != nil {
+			return err
+		}
+		output, err = prmApi.FormatToolVersions(tools, format)
+		if err != nil {
+			return err
+		}
+	} else {
+		err := prmApi.List(toolPath(), "", false)
+		if err != nil {
+			return err
+		}
+		output, err = prmApi.FormatTools(prmApi.Cache, format)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprint(cmd.OutOrStdout(), output)
+	return nil
+}
+
+func executeUninstall(cmd *cobra.Command, args []string) error {
+	removed, err := prmApi.UninstallTool(toolPath(), args[0])
+	if err != nil {
+		return err
+	}
+
+	for _, tool := range removed {
+		log.Info().Msgf("Uninstalled %s/%s %s from %s", tool.Plugin.Author, tool.Plugin.Id, tool.Plugin.Version, tool.Path)
+	}
+
+	if !removeImages {
+		return nil
+	}
+
+	remover, ok := backend().(prm.ToolImageRemoverI)
+	if !ok {
+		log.Warn().Msgf("The %s backend does not build images, so there are none to remove", prmApi.RunningConfig.Backend)
+		return nil
+	}
+	for _, tool := range removed {
+		images, err := remover.RemoveToolImages(&prm.Tool{Cfg: tool})
+		if err != nil {
+			return err
+		}
+		for _, image := range images {
+			log.Info().Msgf("Removed image %s", image)
+		}
+	}
+	return nil
+}
+
+func backend() prm.BackendI {
+	if prmApi.Backend != nil {
+		return prmApi.Backend
+	}
+
+	return prmApi.NewBackend(false)
+}
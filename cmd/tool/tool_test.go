@@ -0,0 +1,117 @@
+package tool_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/puppetlabs/prm/cmd/tool"
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/spf13/afero"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ToolCommand(t *testing.T) {
+	toolPath := "path/to/tools"
+	tests := []struct {
+		name           string
+		args           []string
+		expectedOutput []string
+		missingOutput  []string
+		removedDirs    []string
+		expectError    bool
+	}{
+		{
+			name:           "Should display help when no subcommand passed to 'tool'",
+			args:           []string{""},
+			expectedOutput: []string{"Lists and uninstalls the tools installed in the toolpath"},
+		},
+		{
+			name:           "Should list the newest version of each tool",
+			args:           []string{"list"},
+			expectedOutput: []string{"0.2.0"},
+			missingOutput:  []string{"0.1.0"},
+		},
+		{
+			name:           "Should list every version of each tool",
+			args:           []string{"list", "--all-versions"},
+			expectedOutput: []string{"AUTHOR", "VERSION", "PATH", "0.1.0", "0.2.0"},
+		},
+		{
+			name:        "Should error for an invalid format",
+			args:        []string{"list", "--format", "yaml"},
+			expectError: true,
+		},
+		{
+			name:        "Should uninstall a single version",
+			args:        []string{"uninstall", "puppetlabs/lint@0.1.0"},
+			removedDirs: []string{"puppetlabs/lint/0.1.0"},
+		},
+		{
+			name:        "Should error for a tool in the wrong format",
+			args:        []string{"uninstall", "lint@0.1.0"},
+			expectError: true,
+		},
+		{
+			name:        "Should error for a tool that is not installed",
+			args:        []string{"uninstall", "puppetlabs/epp"},
+			expectError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			afs := &afero.Afero{Fs: fs}
+			for _, version := range []string{"0.1.0", "0.2.0"} {
+				toolDir := filepath.Join(toolPath, "puppetlabs/lint", version)
+				afs.MkdirAll(toolDir, 0750) //nolint:errcheck
+				config := "plugin:\n  author: puppetlabs\n  id: lint\n  display: Lint\n  version: " + version + "\n"
+				afs.WriteFile(filepath.Join(toolDir, "prm-config.yml"), []byte(config), 0644) //nolint:errcheck
+			}
+
+			prmObj := &prm.Prm{
+				AFS:           afs,
+				IOFS:          &afero.IOFS{Fs: fs},
+				RunningConfig: prm.Config{ToolPath: toolPath},
+			}
+			toolCmd := tool.CreateCommand(prmObj)
+			b := bytes.NewBufferString("")
+			toolCmd.SetOutput(b)
+			toolCmd.SetArgs(tt.args)
+
+			err := toolCmd.Execute()
+			if (err != nil) != tt.expectError {
+				t.Errorf("Unexpected error: %s", err)
+				return
+			}
+
+			out, _ := ioutil.ReadAll(b)
+			for _, expected := range tt.expectedOutput {
+				assert.Contains(t, string(out), expected)
+			}
+			for _, missing := range tt.missingOutput {
+				assert.NotContains(t, string(out), missing)
+			}
+			for _, dir := range tt.removedDirs {
+				exists, _ := afs.DirExists(filepath.Join(toolPath, dir))
+				assert.False(t, exists)
+			}
+		})
+	}
+}
+
+// The toolpath setting is bound to the flags of the validate and exec commands,
+// which registering the tool command must not replace
+func Test_ToolCommand_DoesNotBindToolPath(t *testing.T) {
+	viper.Reset()
+	validateFlags := pflag.NewFlagSet("validate", pflag.ContinueOnError)
+	validateFlags.String("toolpath", "", "")
+	assert.NoError(t, viper.BindPFlag("toolpath", validateFlags.Lookup("toolpath")))
+	assert.NoError(t, validateFlags.Parse([]string{"--toolpath", "path/to/validate/tools"}))
+
+	tool.CreateCommand(&prm.Prm{})
+	assert.Equal(t, "path/to/validate/tools", viper.GetString("toolpath"))
+}
@@ -7,7 +7,9 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/Masterminds/semver"
 	"github.com/google/shlex"
 	"github.com/puppetlabs/prm/internal/pkg/utils"
 
@@ -23,6 +25,8 @@ var (
 	format        string
 	selectedTool  string
 	listTools     bool
+	listGroups    bool
+	explain       bool
 	prmApi        *prm.Prm
 	toolArgs      string
 	alwaysBuild   bool
@@ -31,13 +35,24 @@ var (
 	isSerial      bool
 	workerCount   int
 	selectedGroup string
+	// refuse to run tools that may write to the code dir in parallel
+	refuseParallelWrites bool
+	reportFormat         string
+	puppetVersions       string
+	frozen               bool
+	noCache              bool
+	changedSince         string
+	unsupportedTools     string
+	writeBaseline        bool
+	watch                bool
+	failFast             bool
 )
 
 func CreateCommand(parent *prm.Prm) *cobra.Command {
 	prmApi = parent
 
 	tmp := &cobra.Command{
-		Use:               "validate <tool> [overrides|flags]",
+		Use:               "validate <author/id[@version]|group/id> [overrides|flags]",
 		Short:             "Validates Puppet Content with a given tool",
 		Long:              `Validates Puppet Content with a given tool`,
 		Args:              validateArgCount,
@@ -52,6 +67,7 @@ func CreateCommand(parent *prm.Prm) *cobra.Command {
 	err := tmp.RegisterFlagCompletionFunc("list", flagCompletion)
 	cobra.CheckErr(err)
 
+	tmp.Flags().BoolVar(&listGroups, "list-groups", false, "list the tool groups of the validate.yml in the codedir and the built-in tool groups")
 	tmp.Flags().StringVar(&format, "format", "table", "display output in human-readable or json format")
 	err = tmp.RegisterFlagCompletionFunc("format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 		if len(args) != 0 {
@@ -98,10 +114,45 @@ func CreateCommand(parent *prm.Prm) *cobra.Command {
 	err = viper.BindPFlag("workerCount", tmp.Flags().Lookup("workerCount"))
 	cobra.CheckErr(err)
 
-	tmp.Flags().StringVar(&selectedGroup, "group", "", "Select which tool group to use for multi-tool validation. Groups are defined inside of the validate.yml file.")
+	tmp.Flags().StringVar(&selectedGroup, "group", "", "Select which tool group to use for multi-tool validation. Groups are defined inside of the validate.yml file, or are one of the built-in groups, e.g. group/modules.")
 	err = viper.BindPFlag("group", tmp.Flags().Lookup("group"))
 	cobra.CheckErr(err)
 
+	tmp.Flags().BoolVar(&explain, "explain", false, "Print the tools the selected group runs, once its extends and include are resolved, with their args, env and timeout, rather than running them")
+	tmp.Flags().StringVar(&reportFormat, "report-format", "", "Write an aggregate report of the validation results in the given format: junit, json or sarif")
+	err = tmp.RegisterFlagCompletionFunc("report-format", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return prm.ReportFormats, cobra.ShellCompDirectiveNoFileComp
+	})
+	cobra.CheckErr(err)
+	err = viper.BindPFlag("report-format", tmp.Flags().Lookup("report-format"))
+	cobra.CheckErr(err)
+
+	tmp.Flags().StringVar(&puppetVersions, "puppet", "", "Validate against each of the given Puppet versions, as a comma separated list (e.g. '6.19.1,7.15.0') or a range (e.g. '>= 6.0.0 < 8.0.0')")
+
+	tmp.Flags().StringVar(&changedSince, "changed-since", "", "Only validate the files changed since this git ref, passing them to tools in place of "+prm.ChangedFilesPlaceholder+" in their args")
+
+	tmp.Flags().StringVar(&unsupportedTools, "unsupported-tools", "skip", "With --changed-since, whether to 'skip' tools whose args lack "+prm.ChangedFilesPlaceholder+" or run them in 'full'")
+	err = tmp.RegisterFlagCompletionFunc("unsupported-tools", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"skip", "full"}, cobra.ShellCompDirectiveNoFileComp
+	})
+	cobra.CheckErr(err)
+
+	tmp.Flags().BoolVar(&noCache, "no-cache", false, "Run every tool, rather than reusing the cached results of tools whose inputs have not changed")
+	err = viper.BindPFlag("no-cache", tmp.Flags().Lookup("no-cache"))
+	cobra.CheckErr(err)
+
+	tmp.Flags().BoolVar(&writeBaseline, "write-baseline", false, "Record the current failures in "+prm.BaselineFileName+" so that later runs only fail on new findings")
+
+	tmp.Flags().BoolVar(&failFast, "fail-fast", false, "Cancel the remaining tools, stopping any that are running, after the first tool that fails or errors")
+
+	tmp.Flags().BoolVar(&watch, "watch", false, "Keep running, re-validating with the affected tools whenever files in the codedir change")
+
+	tmp.Flags().BoolVar(&frozen, "frozen", false, "Fail, rather than warn, when the tools, images or Puppet version differ from prm.lock")
+
+	tmp.Flags().BoolVar(&refuseParallelWrites, "refuseParallelWrites", false, "Refuse to run tools that need write access to the codedir in parallel with other tools, rather than warning")
+	err = viper.BindPFlag("refuseParallelWrites", tmp.Flags().Lookup("refuseParallelWrites"))
+	cobra.CheckErr(err)
+
 	return tmp
 }
 
@@ -114,6 +165,24 @@ func preExecute(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("the --resultsView flag must be set to either [terminal|file]")
 	}
 
+	if reportFormat != "" && !prm.IsValidReportFormat(reportFormat) {
+		return fmt.Errorf("the --report-format flag must be set to one of [%s]", strings.Join(prm.ReportFormats, "|"))
+	}
+
+	if explain && selectedTool != "" {
+		return fmt.Errorf("the --explain flag explains a tool group, and cannot be used with a single tool")
+	}
+
+	if unsupportedTools != "skip" && unsupportedTools != "full" {
+		return fmt.Errorf("the --unsupported-tools flag must be set to either [skip|full]")
+	}
+
+	if puppetVersions != "" {
+		if _, err := prm.ParsePuppetVersions(puppetVersions); err != nil {
+			return fmt.Errorf("the --puppet flag is invalid: %s", err)
+		}
+	}
+
 	if prmApi.CodeDir == "" {
 		workingDirectory, err := os.Getwd()
 		if err != nil {
@@ -126,14 +195,9 @@ func preExecute(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("the --toolTimeout flag must be set to a value greater than 1")
 	}
 
-	switch prmApi.RunningConfig.Backend {
-	case prm.DOCKER:
-		prmApi.Backend = &prm.Docker{AFS: prmApi.AFS, IOFS: prmApi.IOFS, AlwaysBuild: alwaysBuild, ContextTimeout: prmApi.RunningConfig.Timeout}
-	default:
-		prmApi.Backend = &prm.Docker{AFS: prmApi.AFS, IOFS: prmApi.IOFS, AlwaysBuild: alwaysBuild, ContextTimeout: prmApi.RunningConfig.Timeout}
-	}
+	prmApi.Backend = prmApi.NewBackend(alwaysBuild)
 
-	if !listTools {
+	if !listTools && !listGroups {
 		doesExist, err := prmApi.AFS.DirExists(prmApi.CodeDir)
 		if !doesExist {
 			return fmt.Errorf("the --codedir flag must be set to a valid directory")
@@ -159,7 +223,15 @@ func preExecute(cmd *cobra.Command, args []string) error {
 
 func validateArgCount(cmd *cobra.Command, args []string) error {
 	if len(args) >= 1 {
-		if len(strings.Split(args[0], "/")) != 2 {
+		if strings.HasPrefix(args[0], prm.BuiltinGroupPrefix) {
+			if !prm.IsBuiltinGroup(args[0]) {
+				return fmt.Errorf("built-in tool group '%s' not found, see 'prm validate --list-groups'", args[0])
+			}
+			selectedGroup = args[0]
+			return nil
+		}
+		toolName, _, _ := strings.Cut(args[0], "@")
+		if len(strings.Split(toolName, "/")) != 2 {
 			return fmt.Errorf("Selected tool must be in AUTHOR/ID format")
 		}
 		selectedTool = args[0]
@@ -192,6 +264,11 @@ func completeName(cache string, match string) []string {
 			names = append(names, m)
 		}
 	}
+	for _, group := range prm.BuiltinGroups() {
+		if strings.HasPrefix(group.ID, match) {
+			names = append(names, group.ID+"\tBuilt-in tool group")
+		}
+	}
 	return names
 }
 
@@ -216,11 +293,25 @@ func execute(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	if listGroups {
+		groups, err := prmApi.ValidationGroups()
+		if err != nil {
+			return err
+		}
+		formattedGroups, err := prm.FormatGroups(groups, format)
+		if err != nil {
+			return err
+		}
+		fmt.Print(formattedGroups)
+
+		return nil
+	}
+
 	if selectedTool != "" {
 		// get the tool from the cache
-		cachedTool, ok := prmApi.IsToolAvailable(selectedTool)
-		if !ok {
-			return fmt.Errorf("Tool %s not found in cache", selectedTool)
+		cachedTool, err := prmApi.IsToolAvailable(selectedTool)
+		if err != nil {
+			return err
 		}
 
 		// Default resultsView for single tool validation is "file"
@@ -238,11 +329,32 @@ func execute(cmd *cobra.Command, args []string) error {
 			Args: additionalToolArgs,
 		}
 		settings := prm.OutputSettings{
-			ResultsView: resultsView,
-			OutputDir:   path.Join(prmApi.CodeDir, ".prm-validate"),
+			ResultsView:    resultsView,
+			OutputDir:      path.Join(prmApi.CodeDir, ".prm-validate"),
+			ReportFormat:   reportFormat,
+			UseResultCache: !noCache,
+			WriteBaseline:  writeBaseline,
+			FailFast:       failFast,
+		}
+
+		toolList, err := applyChangedFiles([]prm.ToolInfo{toolInfo})
+		if err != nil || len(toolList) == 0 {
+			return err
+		}
+
+		versions, err := matrixVersions(nil)
+		if err != nil {
+			return err
+		}
+		toolList = prm.ExpandPuppetMatrix(toolList, versions)
+		if err := checkLock("", toolList); err != nil {
+			return err
+		}
+		if isSerial || workerCount < 1 {
+			workerCount = 1
 		}
 
-		err := prmApi.Validate([]prm.ToolInfo{toolInfo}, 1, settings)
+		err = runValidation(cmd, toolList, settings)
 		if err != nil {
 			return err
 		}
@@ -258,6 +370,15 @@ func execute(cmd *cobra.Command, args []string) error {
 			return err
 		}
 
+		if explain {
+			explanation, err := prm.FormatGroupExplanation(toolGroup, format)
+			if err != nil {
+				return err
+			}
+			fmt.Print(explanation)
+			return nil
+		}
+
 		outputDir := path.Join(prmApi.CodeDir, ".prm-validate")
 		if toolGroup.ID != "" {
 			outputDir = path.Join(outputDir, toolGroup.ID)
@@ -266,22 +387,58 @@ func execute(cmd *cobra.Command, args []string) error {
 		// Gather a list of tools
 		var toolList []prm.ToolInfo
 		for _, tool := range toolGroup.Tools {
-			cachedTool, ok := prmApi.IsToolAvailable(tool.Name)
-			if !ok {
-				return fmt.Errorf("Tool %s not found in cache", tool)
+			cachedTool, err := prmApi.IsToolAvailable(tool.Name)
+			if err != nil {
+				return err
 			}
 
-			info := prm.ToolInfo{Tool: cachedTool, Args: tool.Args}
+			info := prm.ToolInfo{
+				Tool:    cachedTool,
+				Args:    tool.Args,
+				ID:      tool.ID,
+				Env:     tool.Env,
+				Timeout: time.Duration(tool.Timeout) * time.Second,
+				Cpus:    tool.Cpus,
+				Memory:  tool.Memory,
+				Needs:   tool.Needs,
+				Stage:   tool.Stage,
+			}
 			toolList = append(toolList, info)
 		}
 
+		toolList, err = applyChangedFiles(toolList)
+		if err != nil || len(toolList) == 0 {
+			return err
+		}
+
+		versions, err := matrixVersions(toolGroup.PuppetVersions)
+		if err != nil {
+			return err
+		}
+		toolList = prm.ExpandPuppetMatrix(toolList, versions)
+		// Only the groups of validate.yml are locked by 'prm lock'
+		lockedGroupID := toolGroup.ID
+		if toolGroup.Builtin {
+			lockedGroupID = ""
+		}
+		if err := checkLock(lockedGroupID, toolList); err != nil {
+			return err
+		}
+
 		if isSerial && cmd.Flags().Changed("workerCount") {
 			log.Warn().Msgf("The --workerCount flag has no affect when used with the --serial flag")
 		}
 		if isSerial || workerCount < 1 {
 			workerCount = 1
 		}
-		err = prmApi.Validate(toolList, workerCount, prm.OutputSettings{ResultsView: resultsView, OutputDir: outputDir})
+		if writers := prm.ParallelCodeDirWriters(toolList, workerCount); len(writers) > 0 {
+			msg := fmt.Sprintf("The following tools may write to the codedir while other tools run in parallel: %s", strings.Join(writers, ", "))
+			if refuseParallelWrites {
+				return fmt.Errorf("%s. Use the --serial flag to run them one at a time", msg)
+			}
+			log.Warn().Msgf("%s. Set 'needs_write_access: false' in their prm-config.yml or use the --serial flag", msg)
+		}
+		err = runValidation(cmd, toolList, prm.OutputSettings{ResultsView: resultsView, OutputDir: outputDir, ReportFormat: reportFormat, UseResultCache: !noCache, WriteBaseline: writeBaseline, FailFast: failFast})
 		if err != nil {
 			return err
 		}
@@ -289,3 +446,74 @@ func execute(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// Validates once, or with --watch until interrupted
+func runValidation(cmd *cobra.Command, toolList []prm.ToolInfo, settings prm.OutputSettings) error {
+	if !watch {
+		return prmApi.Validate(cmd.Context(), toolList, workerCount, settings)
+	}
+	return prmApi.Watch(cmd.Context(), toolList, workerCount, settings, prm.DefaultWatchDebounce)
+}
+
+// The Puppet versions to validate against; the --puppet flag takes precedence over
+// the puppet_versions of the group. Returns nil to use the configured version.
+func matrixVersions(groupVersions []string) ([]*semver.Version, error) {
+	if puppetVersions != "" {
+		return prm.ParsePuppetVersions(puppetVersions)
+	}
+
+	var versions []*semver.Version
+	seen := map[string]bool{}
+	for _, spec := range groupVersions {
+		parsed, err := prm.ParsePuppetVersions(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid puppet_versions in validate.yml: %s", err)
+		}
+		for _, version := range parsed {
+			if !seen[version.String()] {
+				seen[version.String()] = true
+				versions = append(versions, version)
+			}
+		}
+	}
+	return versions, nil
+}
+
+// Warns, or errors with --frozen, when the tools to run differ from prm.lock
+func checkLock(groupID string, toolList []prm.ToolInfo) error {
+	mismatches, err := prmApi.CheckLock(groupID, toolList)
+	if err != nil {
+		return err
+	}
+	if len(mismatches) == 0 {
+		return nil
+	}
+	if frozen {
+		return prm.LockError(mismatches)
+	}
+	log.Warn().Msg(prm.LockError(mismatches).Error())
+	return nil
+}
+
+// Passes the files changed since --changed-since to the tools that accept them,
+// returning no tools when there is nothing to validate
+func applyChangedFiles(toolList []prm.ToolInfo) ([]prm.ToolInfo, error) {
+	var files []string
+	if changedSince != "" {
+		var err error
+		files, err = prmApi.ChangedFiles(changedSince)
+		if err != nil {
+			return nil, err
+		}
+		if files == nil {
+			files = []string{}
+		}
+		log.Info().Msgf("Found %d files changed since %s", len(files), changedSince)
+	}
+
+	scheduled, skipped := prm.ApplyChangedFiles(toolList, files, unsupportedTools == "full")
+	if len(scheduled) == 0 && len(skipped) > 0 {
+		log.Info().Msgf("Nothing to validate: skipped %s", strings.Join(skipped, ", "))
+	}
+	return scheduled, nil
+}
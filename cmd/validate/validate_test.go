@@ -63,6 +63,19 @@ func TestCreateCommand(t *testing.T) {
 			out:     "Selected tool must be in AUTHOR/ID format",
 			wantErr: true,
 		},
+		{
+			name:       "executes without error for a built-in group argument",
+			f:          nullFunction,
+			createDirs: []string{"code/to/validate"},
+			args:       []string{"group/modules", "--codedir", "code/to/validate"},
+		},
+		{
+			name:    "executes with error for an unknown built-in group argument",
+			args:    []string{"group/foo"},
+			f:       nullFunction,
+			out:     "built-in tool group 'group/foo' not found",
+			wantErr: true,
+		},
 		{
 			name:    "executes with error for invalid flag",
 			args:    []string{"--foo"},
@@ -91,6 +104,46 @@ func TestCreateCommand(t *testing.T) {
 			out:     "the --resultsView flag must be set to either [terminal|file]",
 			wantErr: true,
 		},
+		{
+			name:       "executes without error for valid arg for report-format flag",
+			args:       []string{"--codedir", "code/to/validate", "--report-format", "sarif"},
+			f:          nullFunction,
+			createDirs: []string{"code/to/validate"},
+		},
+		{
+			name:    "executes with error for invalid arg for report-format flag",
+			args:    []string{"--report-format", "html"},
+			f:       nullFunction,
+			out:     "the --report-format flag must be set to one of [junit|json|sarif]",
+			wantErr: true,
+		},
+		{
+			name:       "executes without error for a range of puppet versions",
+			args:       []string{"--codedir", "code/to/validate", "--puppet", ">= 6.0.0 < 8.0.0"},
+			f:          nullFunction,
+			createDirs: []string{"code/to/validate"},
+		},
+		{
+			name:    "executes with error for a range without a known puppet version",
+			args:    []string{"--puppet", ">= 9.0.0"},
+			f:       nullFunction,
+			out:     "the --puppet flag is invalid: no known Puppet version satisfies '>= 9.0.0'",
+			wantErr: true,
+		},
+		{
+			name:    "executes with error for invalid puppet flag",
+			args:    []string{"--puppet", "latest"},
+			f:       nullFunction,
+			out:     "the --puppet flag is invalid",
+			wantErr: true,
+		},
+		{
+			name:    "executes with error for invalid unsupported-tools flag",
+			args:    []string{"--changed-since", "main", "--unsupported-tools", "ignore"},
+			f:       nullFunction,
+			out:     "the --unsupported-tools flag must be set to either [skip|full]",
+			wantErr: true,
+		},
 		{
 			name:    "executes with error for invalid toolTimeout flag",
 			args:    []string{"--toolTimeout", "-1"},
@@ -1,6 +1,7 @@
 package mock
 
 import (
+	"context"
 	"errors"
 
 	"github.com/puppetlabs/prm/pkg/prm"
@@ -12,6 +13,17 @@ type MockBackend struct {
 	ToolAvalible        bool
 	ExecReturn          string
 	ValidateReturn      string
+	ValidateStdout      string
+	ValidateStderr      string
+	ValidateArgs        []string // args of the last tool validated
+	// Results of particular tools, by ID, in place of ValidateReturn
+	ValidateReturns map[string]string
+	// Puppet versions of each tool validated, in order
+	ValidatePuppetVersions []string
+	// The number of times Validate was called
+	ValidateCalls int
+	// IDs of the tools validated, in order
+	ValidatedTools []string
 }
 
 func (m *MockBackend) Status() prm.BackendStatus {
@@ -27,20 +39,33 @@ func (m *MockBackend) GetTool(tool *prm.Tool, prmConfig prm.Config) error {
 }
 
 // Implement when needed
-func (m *MockBackend) Validate(toolInfo prm.ToolInfo, prmConfig prm.Config, paths prm.DirectoryPaths) (prm.ValidateExitCode, string, error) {
-	switch m.ValidateReturn {
+func (m *MockBackend) Validate(ctx context.Context, toolInfo prm.ToolInfo, prmConfig prm.Config, paths prm.DirectoryPaths) (prm.ValidateExitCode, prm.ToolOutput, error) {
+	m.ValidateArgs = toolInfo.Args
+	m.ValidateCalls++
+	m.ValidatedTools = append(m.ValidatedTools, toolInfo.Tool.Cfg.Plugin.Id)
+	if prmConfig.PuppetVersion != nil {
+		m.ValidatePuppetVersions = append(m.ValidatePuppetVersions, prmConfig.PuppetVersion.String())
+	}
+	validateReturn := m.ValidateReturn
+	if result, ok := m.ValidateReturns[toolInfo.Tool.Cfg.Plugin.Id]; ok {
+		validateReturn = result
+	}
+	output := prm.ToolOutput{Stdout: m.ValidateStdout, Stderr: m.ValidateStderr, ExitCode: prm.NoExitCode}
+	switch validateReturn {
 	case "PASS":
-		return prm.VALIDATION_PASS, "", nil
+		output.ExitCode = 0
+		return prm.VALIDATION_PASS, output, nil
 	case "FAIL":
-		return prm.VALIDATION_FAILED, "", errors.New("VALIDATION FAIL")
+		output.ExitCode = 1
+		return prm.VALIDATION_FAILED, output, errors.New("VALIDATION FAIL")
 	case "ERROR":
-		return prm.VALIDATION_ERROR, "", errors.New("DOCKER ERROR")
+		return prm.VALIDATION_ERROR, output, errors.New("DOCKER ERROR")
 	default:
-		return prm.VALIDATION_ERROR, "", errors.New("DOCKER FAIL")
+		return prm.VALIDATION_ERROR, output, errors.New("DOCKER FAIL")
 	}
 }
 
-func (m *MockBackend) Exec(tool *prm.Tool, args []string, prmConfig prm.Config, paths prm.DirectoryPaths) (prm.ToolExitCode, error) {
+func (m *MockBackend) Exec(ctx context.Context, tool *prm.Tool, args []string, prmConfig prm.Config, paths prm.DirectoryPaths) (prm.ToolExitCode, error) {
 	switch m.ExecReturn {
 	case "SUCCESS":
 		return prm.SUCCESS, nil
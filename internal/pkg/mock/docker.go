@@ -26,7 +26,24 @@ type DockerClient struct {
 	ExitCode     int64
 	ExitErrorMsg string
 	WantChanErr  bool
-}
+	PullOutput   string
+	PulledImage  string
+	Built        bool
+	// The configuration of the last container created
+	CreatedConfig     *container.Config
+	CreatedHostConfig *container.HostConfig
+	// How long the last container was given to run, from when it was created
+	CreatedTimeout time.Duration
+	// The IDs of the images removed
+	RemovedImages []string
+	// The IDs of the containers stopped and removed
+	StoppedContainers []string
+	RemovedContainers []string
+	ContainersSlice   []types.Container
+}
+
+// The ID of every container the mock creates
+const ContainerID = "test_container"
 
 type ReadClose struct{}
 
@@ -39,6 +56,7 @@ func (re *ReadClose) Close() error {
 }
 
 func (m *DockerClient) ContainerStop(ctx context.Context, container string, timeout *time.Duration) error {
+	m.StoppedContainers = append(m.StoppedContainers, container)
 	return nil
 }
 
@@ -55,7 +73,12 @@ func (m *DockerClient) ServerVersion(ctx context.Context) (types.Version, error)
 }
 
 func (m *DockerClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *specs.Platform, containerName string) (container.ContainerCreateCreatedBody, error) {
-	return container.ContainerCreateCreatedBody{}, nil
+	m.CreatedConfig = config
+	m.CreatedHostConfig = hostConfig
+	if deadline, ok := ctx.Deadline(); ok {
+		m.CreatedTimeout = time.Until(deadline)
+	}
+	return container.ContainerCreateCreatedBody{ID: ContainerID}, nil
 }
 
 func getSrcBuffer(stdOutBytes, stdErrBytes []byte) (buffer *bytes.Buffer, err error) {
@@ -81,6 +104,10 @@ func (cb *ClosingBuffer) Close() (err error) {
 }
 
 func (m *DockerClient) ContainerLogs(ctx context.Context, container string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+	// the logs of a running container are followed until it is cancelled
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
 	stdOutBytes := []byte(m.Stdout)
 	stdErrBytes := []byte(m.Stderr)
 	buffer, err := getSrcBuffer(stdOutBytes, stdErrBytes)
@@ -94,9 +121,14 @@ func (m *DockerClient) ContainerLogs(ctx context.Context, container string, opti
 }
 
 func (m *DockerClient) ContainerRemove(ctx context.Context, containerID string, options types.ContainerRemoveOptions) error {
+	m.RemovedContainers = append(m.RemovedContainers, containerID)
 	return nil
 }
 
+func (m *DockerClient) ContainerList(ctx context.Context, options types.ContainerListOptions) ([]types.Container, error) {
+	return m.ContainersSlice, nil
+}
+
 func (m *DockerClient) ContainerStart(ctx context.Context, containerID string, options types.ContainerStartOptions) error {
 	return nil
 }
@@ -113,14 +145,21 @@ func (m *DockerClient) ContainerWait(ctx context.Context, containerID string, co
 }
 
 func (m *DockerClient) ImageBuild(ctx context.Context, buildContext io.Reader, options types.ImageBuildOptions) (types.ImageBuildResponse, error) {
+	m.Built = true
 	return types.ImageBuildResponse{Body: &ReadClose{}}, nil
 }
 
+func (m *DockerClient) ImagePull(ctx context.Context, refStr string, options types.ImagePullOptions) (io.ReadCloser, error) {
+	m.PulledImage = refStr
+	return &ClosingBuffer{bytes.NewBufferString(m.PullOutput)}, nil
+}
+
 func (m *DockerClient) ImageList(ctx context.Context, options types.ImageListOptions) ([]types.ImageSummary, error) {
 	return m.ImagesSlice, nil
 }
 
 func (m *DockerClient) ImageRemove(ctx context.Context, imageID string, options types.ImageRemoveOptions) ([]types.ImageDeleteResponseItem, error) {
+	m.RemovedImages = append(m.RemovedImages, imageID)
 	return []types.ImageDeleteResponseItem{{Deleted: "test_id"}}, nil
 }
 
@@ -0,0 +1,35 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/puppetlabs/prm/pkg/prm"
+)
+
+type LocalRunner struct {
+	Executables map[string]string // Key = name looked up, Value = resolved path
+	ExitCode    int
+	Stdout      string
+	Stderr      string
+	ErrorString string
+	LastCommand prm.LocalCommand
+}
+
+func (m *LocalRunner) LookPath(file string) (string, error) {
+	if path, ok := m.Executables[file]; ok {
+		return path, nil
+	}
+	return "", fmt.Errorf("exec: %q: executable file not found in $PATH", file)
+}
+
+func (m *LocalRunner) Run(ctx context.Context, cmd prm.LocalCommand, stdout io.Writer, stderr io.Writer) (int, error) {
+	m.LastCommand = cmd
+	if m.ErrorString != "" {
+		return -1, fmt.Errorf(m.ErrorString)
+	}
+	_, _ = io.WriteString(stdout, m.Stdout)
+	_, _ = io.WriteString(stderr, m.Stderr)
+	return m.ExitCode, nil
+}
@@ -2,9 +2,10 @@ package main
 
 import (
 	"context"
-	"github.com/rs/zerolog/log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
 	cmd_build "github.com/puppetlabs/pct/cmd/build"
 	"github.com/puppetlabs/pct/pkg/build"
@@ -13,18 +14,24 @@ import (
 	"github.com/puppetlabs/pct/pkg/install"
 	"github.com/puppetlabs/pct/pkg/tar"
 	"github.com/puppetlabs/pct/pkg/telemetry"
+	"github.com/puppetlabs/prm/cmd/clean"
+	"github.com/puppetlabs/prm/cmd/config"
+	"github.com/puppetlabs/prm/cmd/doctor"
 	"github.com/puppetlabs/prm/cmd/exec"
 	"github.com/puppetlabs/prm/cmd/explain"
 	"github.com/puppetlabs/prm/cmd/get"
 	cmd_install "github.com/puppetlabs/prm/cmd/install"
+	"github.com/puppetlabs/prm/cmd/lock"
 	"github.com/puppetlabs/prm/cmd/root"
 	"github.com/puppetlabs/prm/cmd/set"
 	"github.com/puppetlabs/prm/cmd/status"
+	"github.com/puppetlabs/prm/cmd/tool"
 	"github.com/puppetlabs/prm/cmd/validate"
 	appver "github.com/puppetlabs/prm/cmd/version"
 	"github.com/puppetlabs/prm/internal/pkg/config_processor"
 	"github.com/puppetlabs/prm/pkg/prm"
 	"github.com/puppetlabs/prm/pkg/utils"
+	"github.com/rs/zerolog/log"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 )
@@ -71,12 +78,24 @@ func main() {
 	// get command
 	rootCmd.AddCommand(get.CreateGetCommand(prmApi))
 
+	// config command
+	rootCmd.AddCommand(config.CreateCommand(prmApi))
+
 	// exec command
 	rootCmd.AddCommand(exec.CreateCommand(prmApi))
 
 	// validate command
 	rootCmd.AddCommand(validate.CreateCommand(prmApi))
 
+	// lock command
+	rootCmd.AddCommand(lock.CreateCommand(prmApi))
+
+	// clean command
+	rootCmd.AddCommand(clean.CreateCommand(prmApi))
+
+	// doctor command
+	rootCmd.AddCommand(doctor.CreateCommand(prmApi))
+
 	// status command
 	rootCmd.AddCommand(status.CreateStatusCommand(prmApi))
 
@@ -113,15 +132,32 @@ func main() {
 	}
 	rootCmd.AddCommand(installCmd.CreateCommand())
 
+	// tool command
+	rootCmd.AddCommand(tool.CreateCommand(prmApi))
+
 	// explain
 	rootCmd.AddCommand(explain.CreateCommand())
 
 	// initialize
 	cobra.OnInitialize(root.InitLogger, root.InitConfig)
 
+	// Interrupting PRM cancels the command's context, so that the tools it
+	// is running are stopped and their containers removed before it exits
+	cmdCtx, cancel := context.WithCancel(ctx)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		log.Warn().Msg("Interrupted, stopping running tools. Interrupt again to exit immediately")
+		// a second interrupt exits immediately
+		signal.Reset(os.Interrupt, syscall.SIGTERM)
+		cancel()
+	}()
+
 	// instrument & execute called command
-	ctx, childSpan := telemetry.NewSpan(ctx, calledCommand)
-	err := rootCmd.ExecuteContext(ctx)
+	cmdCtx, childSpan := telemetry.NewSpan(cmdCtx, calledCommand)
+	err := rootCmd.ExecuteContext(cmdCtx)
+	cancel()
 	telemetry.RecordSpanError(childSpan, err)
 	telemetry.EndSpan(childSpan)
 
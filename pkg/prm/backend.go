@@ -1,19 +1,54 @@
 //nolint:structcheck,unused
 package prm
 
+import (
+	"context"
+	"time"
+
+	"github.com/Masterminds/semver"
+)
+
 type BackendType string
 
 const (
 	DOCKER BackendType = "docker"
+	PODMAN BackendType = "podman"
+	LOCAL  BackendType = "local"
 )
 
 type BackendI interface {
 	GetTool(tool *Tool, prmConfig Config) error
-	Validate(toolInfo ToolInfo, prmConfig Config, paths DirectoryPaths) (ValidateExitCode, string, error)
-	Exec(tool *Tool, args []string, prmConfig Config, paths DirectoryPaths) (ToolExitCode, error)
+	// Validate stops the tool when ctx is done
+	Validate(ctx context.Context, toolInfo ToolInfo, prmConfig Config, paths DirectoryPaths) (ValidateExitCode, ToolOutput, error)
+	// Exec stops the tool when ctx is done
+	Exec(ctx context.Context, tool *Tool, args []string, prmConfig Config, paths DirectoryPaths) (ToolExitCode, error)
 	Status() BackendStatus
 }
 
+// Returns the error reported to the user when the configured
+// backend is not available
+func (p *Prm) errBackendNotRunning() error {
+	switch p.RunningConfig.Backend {
+	case PODMAN:
+		return ErrPodmanNotRunning
+	default:
+		return ErrDockerNotRunning
+	}
+}
+
+// NewBackend creates the backend the config selects, defaulting to Docker.
+// With alwaysBuild set, the images of tools are rebuilt even if they exist.
+func (p *Prm) NewBackend(alwaysBuild bool) BackendI {
+	switch p.RunningConfig.Backend {
+	case PODMAN:
+		return &Podman{Docker: Docker{AFS: p.AFS, IOFS: p.IOFS, AlwaysBuild: alwaysBuild, ContextTimeout: p.RunningConfig.Timeout}}
+	case LOCAL:
+		return &Local{AFS: p.AFS, ContextTimeout: p.RunningConfig.Timeout}
+	default:
+		return &Docker{AFS: p.AFS, IOFS: p.IOFS, AlwaysBuild: alwaysBuild, ContextTimeout: p.RunningConfig.Timeout}
+	}
+}
+
 // The BackendStatus must report whether the backend is available
 // and any useful status information; in the case of the backend
 // being unavailable, report the error message to the user.
@@ -28,13 +63,57 @@ type DirectoryPaths struct {
 }
 
 type OutputSettings struct {
-	ResultsView string // Either "terminal" or "file"
-	OutputDir   string // Directory to write log file to
+	ResultsView  string // Either "terminal" or "file"
+	OutputDir    string // Directory to write log file to
+	ReportFormat string // Either "junit", "json", "sarif" or empty for no report
+	// Reuse the results of tools whose inputs have not changed since they last ran
+	UseResultCache bool
+	// Record the current failures in the baseline so only new ones fail validation
+	WriteBaseline bool
+	// Cancel the remaining tools after the first that fails or errors
+	FailFast bool
 }
 
 type ToolInfo struct {
 	Tool *Tool
 	Args []string
+	// Identifies this run of the tool in results and logs; defaults to the ID of the tool
+	ID string
+	// Overrides the configured Puppet version, e.g. when validating a matrix of versions
+	PuppetVersion *semver.Version
+	// Environment variables set for this run of the tool
+	Env map[string]string
+	// Overrides the configured tool timeout when set
+	Timeout time.Duration
+	// Override the CPUs and memory of the tool's prm-config.yml when set
+	Cpus   float64
+	Memory string
+	// Names of the tools that must pass before this one runs
+	Needs []string
+	// The stage of its group the tool runs in, or 0 outside of the stages
+	Stage int
+}
+
+// The time to wait for the tool: that of this run, else that of its prm-config.yml,
+// or zero to use the configured tool timeout
+func (info ToolInfo) timeout() time.Duration {
+	if info.Timeout > 0 {
+		return info.Timeout
+	}
+	if info.Tool != nil && info.Tool.Cfg.Common.Timeout > 0 {
+		return time.Duration(info.Tool.Cfg.Common.Timeout) * time.Second
+	}
+	return 0
+}
+
+// NoExitCode is the exit code of a tool that did not run to completion
+const NoExitCode = -1
+
+// ToolOutput is what a tool wrote while validating, and the code its process exited with
+type ToolOutput struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
 }
 
 type ContainerOutput struct {
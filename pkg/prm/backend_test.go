@@ -0,0 +1,51 @@
+package prm_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrm_NewBackend(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afs := &afero.Afero{Fs: fs}
+	iofs := &afero.IOFS{Fs: fs}
+	timeout := 30 * time.Second
+
+	tests := []struct {
+		name        string
+		backend     prm.BackendType
+		alwaysBuild bool
+		want        prm.BackendI
+	}{
+		{
+			name:        "Docker",
+			backend:     prm.DOCKER,
+			alwaysBuild: true,
+			want:        &prm.Docker{AFS: afs, IOFS: iofs, AlwaysBuild: true, ContextTimeout: timeout},
+		},
+		{
+			name:    "Podman",
+			backend: prm.PODMAN,
+			want:    &prm.Podman{Docker: prm.Docker{AFS: afs, IOFS: iofs, ContextTimeout: timeout}},
+		},
+		{
+			name:    "Local",
+			backend: prm.LOCAL,
+			want:    &prm.Local{AFS: afs, ContextTimeout: timeout},
+		},
+		{
+			name: "Docker by default",
+			want: &prm.Docker{AFS: afs, IOFS: iofs, ContextTimeout: timeout},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &prm.Prm{AFS: afs, IOFS: iofs, RunningConfig: prm.Config{Backend: tt.backend, Timeout: timeout}}
+			assert.Equal(t, tt.want, p.NewBackend(tt.alwaysBuild))
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package prm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+)
+
+const BaselineFileName = ".prm-baseline.json"
+
+// Baseline records the failures that validation should tolerate, so that
+// only new findings fail it
+type Baseline struct {
+	Tools map[string]BaselineEntry `json:"tools"`
+}
+
+// BaselineEntry holds the fingerprints of a tool's findings or,
+// for tools without structured output, of its output
+type BaselineEntry struct {
+	Findings []string `json:"findings,omitempty"`
+	Output   string   `json:"output,omitempty"`
+}
+
+// Identifies a finding without its line, so it survives unrelated edits to the file
+func findingFingerprint(finding Finding) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s\x00%s", finding.File, finding.Severity, finding.Rule, finding.Message)))
+	return hex.EncodeToString(hash[:])
+}
+
+func outputFingerprint(output ValidationOutput) string {
+	errText := ""
+	if output.err != nil {
+		errText = output.err.Error()
+	}
+	hash := sha256.Sum256([]byte(cleanOutput(output.stdout) + "\x00" + cleanOutput(errText)))
+	return hex.EncodeToString(hash[:])
+}
+
+// Only tools that ran and found fault with the content can be baselined
+func isBaselineCandidate(output ValidationOutput) bool {
+	return output.err != nil && output.exitCode == VALIDATION_FAILED
+}
+
+func (p *Prm) baselinePath() string {
+	return filepath.Join(p.CodeDir, BaselineFileName)
+}
+
+// Reads the codedir's baseline, returning nil if there is none
+func (p *Prm) readBaseline() (*Baseline, error) {
+	if exists, _ := p.AFS.Exists(p.baselinePath()); !exists {
+		return nil, nil
+	}
+	content, err := p.AFS.ReadFile(p.baselinePath())
+	if err != nil {
+		return nil, err
+	}
+	var baseline Baseline
+	if err := json.Unmarshal(content, &baseline); err != nil {
+		return nil, fmt.Errorf("%s is not formatted correctly: %s", BaselineFileName, err)
+	}
+	return &baseline, nil
+}
+
+// Records the failures of the tools in the baseline, replacing any
+// previous entries for them and keeping those of other tools
+func (p *Prm) writeBaseline(tasks []*Task[ValidationOutput]) error {
+	baseline, err := p.readBaseline()
+	if err != nil {
+		return err
+	}
+	if baseline == nil {
+		baseline = &Baseline{}
+	}
+	if baseline.Tools == nil {
+		baseline.Tools = map[string]BaselineEntry{}
+	}
+
+	recorded := 0
+	for _, task := range tasks {
+		output := task.Output
+		if !isBaselineCandidate(output) {
+			delete(baseline.Tools, task.Name)
+			continue
+		}
+
+		entry := BaselineEntry{}
+		if output.findings != nil {
+			for _, finding := range output.findings {
+				entry.Findings = append(entry.Findings, findingFingerprint(finding))
+			}
+			sort.Strings(entry.Findings)
+		} else {
+			entry.Output = outputFingerprint(output)
+		}
+		baseline.Tools[task.Name] = entry
+		recorded++
+	}
+
+	content, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = p.AFS.WriteFile(p.baselinePath(), content, 0644)
+	if err != nil {
+		return fmt.Errorf("unable to write %s: %s", p.baselinePath(), err)
+	}
+	log.Info().Msgf("Wrote the current failures of %d tools to %s", recorded, p.baselinePath())
+	return nil
+}
+
+// Suppresses the failures recorded in the baseline, leaving each tool with
+// only its new findings; a tool with none left passes
+func (p *Prm) applyBaseline(tasks []*Task[ValidationOutput]) error {
+	baseline, err := p.readBaseline()
+	if err != nil || baseline == nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		task.Output = baseline.suppress(task.Name, task.Output)
+	}
+	return nil
+}
+
+// Suppresses the failures of the named tool recorded in the baseline
+func (b *Baseline) suppress(name string, output ValidationOutput) ValidationOutput {
+	if b == nil {
+		return output
+	}
+	entry, ok := b.Tools[name]
+	if !ok || !isBaselineCandidate(output) {
+		return output
+	}
+
+	if output.findings == nil {
+		if entry.Output != "" && entry.Output == outputFingerprint(output) {
+			output.baselined = -1
+			output.err = nil
+		}
+		return output
+	}
+
+	known := map[string]int{}
+	for _, fingerprint := range entry.Findings {
+		known[fingerprint]++
+	}
+	newFindings := []Finding{}
+	for _, finding := range output.findings {
+		fingerprint := findingFingerprint(finding)
+		if known[fingerprint] > 0 {
+			known[fingerprint]--
+			output.baselined++
+			continue
+		}
+		newFindings = append(newFindings, finding)
+	}
+	output.findings = newFindings
+	if len(newFindings) == 0 {
+		output.err = nil
+	}
+	return output
+}
+
+// Describes the failures of a tool that the baseline suppressed
+func baselineSummary(output ValidationOutput) string {
+	switch {
+	case output.baselined < 0:
+		return "output suppressed"
+	case output.baselined > 0:
+		return fmt.Sprintf("%d suppressed", output.baselined)
+	}
+	return ""
+}
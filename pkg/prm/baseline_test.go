@@ -0,0 +1,142 @@
+package prm_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/puppetlabs/prm/internal/pkg/mock"
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrm_Validate_Baseline(t *testing.T) {
+	legacyFindings := `[
+  {"path": "manifests/init.pp", "line": 3, "kind": "warning", "check": "140chars", "message": "line has more than 140 characters"},
+  {"path": "manifests/init.pp", "line": 9, "kind": "error", "check": "arrow_alignment", "message": "indentation of => is not properly aligned"}
+]`
+	tests := []struct {
+		name       string
+		outputMode *prm.OutputModes
+		stdout     string
+		validate   string
+		wantErr    string
+	}{
+		{
+			name:       "Baselined findings on other lines pass",
+			outputMode: &prm.OutputModes{Json: "--json"},
+			stdout: `[
+  {"path": "manifests/init.pp", "line": 4, "kind": "warning", "check": "140chars", "message": "line has more than 140 characters"},
+  {"path": "manifests/init.pp", "line": 10, "kind": "error", "check": "arrow_alignment", "message": "indentation of => is not properly aligned"}
+]`,
+			validate: "FAIL",
+		},
+		{
+			name:       "New findings fail",
+			outputMode: &prm.OutputModes{Json: "--json"},
+			stdout: `[
+  {"path": "manifests/init.pp", "line": 3, "kind": "warning", "check": "140chars", "message": "line has more than 140 characters"},
+  {"path": "manifests/init.pp", "line": 9, "kind": "error", "check": "arrow_alignment", "message": "indentation of => is not properly aligned"},
+  {"path": "manifests/config.pp", "line": 1, "kind": "error", "check": "documentation", "message": "class not documented"}
+]`,
+			validate: "FAIL",
+			wantErr:  "Validation returned 1 error",
+		},
+		{
+			name:       "A repeated finding is new",
+			outputMode: &prm.OutputModes{Json: "--json"},
+			stdout: `[
+  {"path": "manifests/init.pp", "line": 3, "kind": "warning", "check": "140chars", "message": "line has more than 140 characters"},
+  {"path": "manifests/init.pp", "line": 5, "kind": "warning", "check": "140chars", "message": "line has more than 140 characters"}
+]`,
+			validate: "FAIL",
+			wantErr:  "Validation returned 1 error",
+		},
+		{
+			name:     "Unchanged output of a tool without findings passes",
+			stdout:   legacyFindings,
+			validate: "FAIL",
+		},
+		{
+			name:     "Changed output of a tool without findings fails",
+			stdout:   "something else went wrong",
+			validate: "FAIL",
+			wantErr:  "Validation returned 1 error",
+		},
+		{
+			name:       "Errors running the tool are not suppressed",
+			outputMode: &prm.OutputModes{Json: "--json"},
+			stdout:     legacyFindings,
+			validate:   "ERROR",
+			wantErr:    "Validation returned 1 error",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			afs := &afero.Afero{Fs: fs}
+			afs.MkdirAll("path/to/code", 0750) //nolint:errcheck
+
+			backend := &mock.MockBackend{StatusIsAvailable: true, ToolAvalible: true, ValidateReturn: "FAIL", ValidateStdout: legacyFindings}
+			p := &prm.Prm{AFS: afs, IOFS: &afero.IOFS{Fs: fs}, CodeDir: "path/to/code", Backend: backend}
+			toolInfo := CreateToolInfo("lint", "puppetlabs", "0.1.0", nil)
+			toolInfo.Tool.Cfg.Common.OutputMode = tt.outputMode
+
+			// Without a baseline the legacy findings fail validation
+			settings := prm.OutputSettings{ResultsView: "terminal"}
+			assert.EqualError(t, p.Validate(context.Background(), []prm.ToolInfo{toolInfo}, 1, settings), "Validation returned 1 error")
+
+			settings.WriteBaseline = true
+			assert.NoError(t, p.Validate(context.Background(), []prm.ToolInfo{toolInfo}, 1, settings))
+			exists, _ := afs.Exists("path/to/code/" + prm.BaselineFileName)
+			assert.True(t, exists)
+
+			backend.ValidateStdout = tt.stdout
+			backend.ValidateReturn = tt.validate
+			settings.WriteBaseline = false
+			err := p.Validate(context.Background(), []prm.ToolInfo{toolInfo}, 1, settings)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPrm_Validate_Baseline_SkipAndCancel(t *testing.T) {
+	tests := []struct {
+		name     string
+		needs    bool
+		failFast bool
+	}{
+		{name: "A baselined failure does not skip the tools that need it", needs: true},
+		{name: "A baselined failure does not cancel the other tools", failFast: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			afs := &afero.Afero{Fs: fs}
+			afs.MkdirAll("path/to/code", 0750) //nolint:errcheck
+
+			backend := &mock.MockBackend{StatusIsAvailable: true, ToolAvalible: true, ValidateReturn: "PASS", ValidateReturns: map[string]string{"cache": "FAIL"}}
+			p := &prm.Prm{AFS: afs, IOFS: &afero.IOFS{Fs: fs}, CodeDir: "path/to/code", Backend: backend}
+			cache := CreateToolInfo("cache", "puppetlabs", "0.1.0", nil)
+			spec := CreateToolInfo("spec", "puppetlabs", "0.1.0", nil)
+			if tt.needs {
+				spec.Needs = []string{"puppetlabs/cache"}
+			}
+			tools := []prm.ToolInfo{cache, spec}
+			settings := prm.OutputSettings{ResultsView: "terminal", FailFast: tt.failFast}
+
+			// The tools that never ran while writing the baseline fail validation
+			settings.WriteBaseline = true
+			assert.EqualError(t, p.Validate(context.Background(), tools, 1, settings), "Validation did not run 1 tool after a failure")
+
+			backend.ValidatedTools = nil
+			settings.WriteBaseline = false
+			assert.NoError(t, p.Validate(context.Background(), tools, 1, settings))
+			assert.Equal(t, []string{"cache", "spec"}, backend.ValidatedTools)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package prm
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ChangedFilesPlaceholder is replaced in a tool's args, as given in validate.yml
+// or with --toolArgs, with the files changed since the ref given to --changed-since.
+// Tools whose args do not contain it do not support validating a list of files.
+const ChangedFilesPlaceholder = "{{changed_files}}"
+
+// ChangedFiles lists the files under the codedir, relative to it, that were
+// added or modified since the git ref, including uncommitted and untracked files
+func (p *Prm) ChangedFiles(ref string) ([]string, error) {
+	changed, err := p.git("diff", "--name-only", "--relative", "--diff-filter=d", ref, "--")
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the files changed since '%s': %s", ref, err)
+	}
+	untracked, err := p.git("ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the untracked files: %s", err)
+	}
+
+	seen := map[string]bool{}
+	var files []string
+	for _, file := range append(changed, untracked...) {
+		if !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Runs git in the codedir, returning the lines it output
+func (p *Prm) git(args ...string) ([]string, error) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = p.CodeDir
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s", msg)
+		}
+		return nil, err
+	}
+
+	var lines []string
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// SupportsChangedFiles reports whether the tool would be passed the changed files
+func (t ToolInfo) SupportsChangedFiles() bool {
+	for _, arg := range t.Args {
+		if strings.Contains(arg, ChangedFilesPlaceholder) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplyChangedFiles replaces the changed files placeholder in each tool's args.
+// With a nil list of files every tool validates in full, so the placeholder is
+// dropped, leaving a tool with no other args to run with its default args.
+// Otherwise tools that do not support a list of files are skipped, or run in
+// full if runUnsupported is set, and the names of the skipped tools returned.
+func ApplyChangedFiles(toolsInfo []ToolInfo, files []string, runUnsupported bool) ([]ToolInfo, []string) {
+	var scheduled []ToolInfo
+	var skipped []string
+	for _, info := range toolsInfo {
+		name := fmt.Sprintf("%s/%s", info.Tool.Cfg.Plugin.Author, info.Tool.Cfg.Plugin.Id)
+		if !info.SupportsChangedFiles() {
+			if files != nil && !runUnsupported {
+				log.Info().Msgf("Skipping %s as its args do not include %s", name, ChangedFilesPlaceholder)
+				skipped = append(skipped, name)
+				continue
+			}
+			scheduled = append(scheduled, info)
+			continue
+		}
+
+		if files != nil && len(files) == 0 {
+			log.Info().Msgf("Skipping %s as no files have changed", name)
+			skipped = append(skipped, name)
+			continue
+		}
+		info.Args = expandChangedFiles(info.Args, files)
+		scheduled = append(scheduled, info)
+	}
+	return scheduled, skipped
+}
+
+// An arg that is just the placeholder becomes one arg per file;
+// a placeholder within an arg is replaced with the files separated by spaces
+func expandChangedFiles(args []string, files []string) []string {
+	expanded := []string{}
+	for _, arg := range args {
+		switch {
+		case arg == ChangedFilesPlaceholder:
+			expanded = append(expanded, files...)
+		case strings.Contains(arg, ChangedFilesPlaceholder):
+			arg = strings.TrimSpace(strings.ReplaceAll(arg, ChangedFilesPlaceholder, strings.Join(files, " ")))
+			if arg != "" {
+				expanded = append(expanded, arg)
+			}
+		default:
+			expanded = append(expanded, arg)
+		}
+	}
+	return expanded
+}
@@ -0,0 +1,124 @@
+package prm_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyChangedFiles(t *testing.T) {
+	files := []string{"manifests/init.pp", "manifests/config.pp"}
+	incremental := CreateToolInfo("lint", "puppetlabs", "0.1.0", []string{"--fail-on-warnings", prm.ChangedFilesPlaceholder})
+	embedded := CreateToolInfo("parser", "puppetlabs", "0.1.0", []string{"--files=" + prm.ChangedFilesPlaceholder})
+	onlyPlaceholder := CreateToolInfo("epp", "puppetlabs", "0.1.0", []string{prm.ChangedFilesPlaceholder})
+	full := CreateToolInfo("rspec", "puppetlabs", "0.1.0", nil)
+
+	tests := []struct {
+		name           string
+		files          []string
+		runUnsupported bool
+		wantArgs       map[string][]string
+		wantSkipped    []string
+	}{
+		{
+			name:  "Changed files replace the placeholder",
+			files: files,
+			wantArgs: map[string][]string{
+				"lint":   {"--fail-on-warnings", "manifests/init.pp", "manifests/config.pp"},
+				"parser": {"--files=manifests/init.pp manifests/config.pp"},
+				"epp":    {"manifests/init.pp", "manifests/config.pp"},
+			},
+			wantSkipped: []string{"puppetlabs/rspec"},
+		},
+		{
+			name:           "Unsupported tools can run in full",
+			files:          files,
+			runUnsupported: true,
+			wantArgs: map[string][]string{
+				"lint":   {"--fail-on-warnings", "manifests/init.pp", "manifests/config.pp"},
+				"parser": {"--files=manifests/init.pp manifests/config.pp"},
+				"epp":    {"manifests/init.pp", "manifests/config.pp"},
+				"rspec":  nil,
+			},
+		},
+		{
+			name:  "Without changed files every tool validates in full",
+			files: nil,
+			wantArgs: map[string][]string{
+				"lint":   {"--fail-on-warnings"},
+				"parser": {"--files="},
+				"epp":    {},
+				"rspec":  nil,
+			},
+		},
+		{
+			name:           "Tools are skipped when no files have changed",
+			files:          []string{},
+			runUnsupported: true,
+			wantArgs:       map[string][]string{"rspec": nil},
+			wantSkipped:    []string{"puppetlabs/lint", "puppetlabs/parser", "puppetlabs/epp"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduled, skipped := prm.ApplyChangedFiles([]prm.ToolInfo{incremental, embedded, onlyPlaceholder, full}, tt.files, tt.runUnsupported)
+
+			gotArgs := map[string][]string{}
+			for _, info := range scheduled {
+				gotArgs[info.Tool.Cfg.Plugin.Id] = info.Args
+			}
+			assert.Equal(t, tt.wantArgs, gotArgs)
+			assert.Equal(t, tt.wantSkipped, skipped)
+		})
+	}
+}
+
+func TestPrm_ChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repo := t.TempDir()
+	codeDir := filepath.Join(repo, "modules", "motd")
+	writeFile := func(file string, content string) {
+		path := filepath.Join(repo, file)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+		assert.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+	git := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=prm", "-c", "user.email=prm@example.com"}, args...)...)
+		cmd.Dir = repo
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+	}
+
+	git("init", "-q")
+	writeFile("modules/motd/manifests/init.pp", "class motd {}")
+	writeFile("modules/motd/manifests/removed.pp", "class motd::removed {}")
+	writeFile("modules/other/manifests/init.pp", "class other {}")
+	writeFile(".gitignore", "*.swp\n")
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+	git("tag", "base")
+
+	writeFile("modules/motd/manifests/init.pp", "class motd { }")
+	writeFile("modules/motd/manifests/committed.pp", "class motd::committed {}")
+	git("add", "-A")
+	git("commit", "-q", "-m", "change")
+	writeFile("modules/motd/manifests/untracked.pp", "class motd::untracked {}")
+	writeFile("modules/motd/manifests/init.pp.swp", "swap")
+	writeFile("modules/other/manifests/init.pp", "class other { }")
+	assert.NoError(t, os.Remove(filepath.Join(codeDir, "manifests/removed.pp")))
+
+	p := &prm.Prm{CodeDir: codeDir}
+	files, err := p.ChangedFiles("base")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"manifests/committed.pp", "manifests/init.pp", "manifests/untracked.pp"}, files)
+
+	_, err = p.ChangedFiles("no-such-ref")
+	assert.ErrorContains(t, err, "unable to list the files changed since 'no-such-ref'")
+}
@@ -0,0 +1,326 @@
+package prm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/olekukonko/tablewriter"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	CleanKindImage     = "image"
+	CleanKindContainer = "container"
+	CleanKindBuildFile = "build file"
+	CleanKindCache     = "cache"
+	CleanKindLog       = "log"
+
+	generatedDockerfile = "generated.Dockerfile"
+)
+
+// BackendCleanerI is implemented by backends that build images
+// for tools and run them in containers
+type BackendCleanerI interface {
+	ToolImages() ([]BackendResource, error)
+	ToolContainers() ([]BackendResource, error)
+	RemoveImage(id string) error
+	RemoveContainer(id string) error
+}
+
+// An image or container the backend holds for PRM
+type BackendResource struct {
+	ID      string
+	Name    string
+	Size    int64
+	Created time.Time
+	Running bool
+}
+
+// CleanOptions selects what 'prm clean' removes. When none of
+// Images, Containers, Cache or Logs is set, everything is selected,
+// unless Unused is set, which selects images on its own.
+type CleanOptions struct {
+	Images     bool // tool images and the Dockerfiles generated to build them
+	Containers bool
+	Cache      bool
+	Logs       bool
+	// Only select items last modified longer ago than this
+	OlderThan time.Duration
+	// Only select images of tool versions that are no longer installed
+	Unused   bool
+	ToolPath string
+}
+
+// CleanItem is something 'prm clean' can remove
+type CleanItem struct {
+	Kind     string
+	Name     string
+	Size     int64
+	Modified time.Time
+
+	remove func() error
+}
+
+// FindCleanItems lists what the options select for removal, without removing anything
+func (p *Prm) FindCleanItems(options CleanOptions) ([]CleanItem, error) {
+	// Only images can be unused, so asking for them selects images
+	if options.Unused {
+		options.Images = true
+	}
+	all := !options.Images && !options.Containers && !options.Cache && !options.Logs
+
+	var items []CleanItem
+	cleaner, isCleaner := p.Backend.(BackendCleanerI)
+	if (all || options.Images || options.Containers) && !isCleaner {
+		log.Debug().Msgf("The %s backend has no images or containers to remove", p.RunningConfig.Backend)
+	}
+	if (all || options.Images) && isCleaner {
+		images, err := p.imageItems(cleaner, options)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, images...)
+	}
+	if all || options.Images {
+		items = append(items, p.buildFileItems(options.ToolPath)...)
+	}
+	if (all || options.Containers) && isCleaner {
+		containers, err := containerItems(cleaner)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, containers...)
+	}
+	if all || options.Cache {
+		cache, err := p.cacheItems()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, cache...)
+	}
+	if all || options.Logs {
+		logs, err := p.logItems()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, logs...)
+	}
+
+	if options.OlderThan > 0 {
+		cutoff := time.Now().Add(-options.OlderThan)
+		var older []CleanItem
+		for _, item := range items {
+			if item.Modified.Before(cutoff) {
+				older = append(older, item)
+			}
+		}
+		items = older
+	}
+	return items, nil
+}
+
+// Clean removes the items, returning the space freed. Every item is
+// attempted; the items that could not be removed are reported in the error.
+func (p *Prm) Clean(items []CleanItem) (int64, error) {
+	var freed int64
+	var failed []string
+	for _, item := range items {
+		if err := item.remove(); err != nil {
+			log.Error().Msgf("Unable to remove %s %s: %s", item.Kind, item.Name, err)
+			failed = append(failed, item.Name)
+			continue
+		}
+		log.Debug().Msgf("Removed %s %s", item.Kind, item.Name)
+		freed += item.Size
+	}
+	if len(failed) > 0 {
+		return freed, fmt.Errorf("unable to remove %s", strings.Join(failed, ", "))
+	}
+	return freed, nil
+}
+
+// FormatCleanItems formats the items as a table, with the total space they take up
+func FormatCleanItems(items []CleanItem) string {
+	stringBuilder := &strings.Builder{}
+	table := tablewriter.NewWriter(stringBuilder)
+	table.SetHeader([]string{"Kind", "Name", "Size", "Modified"})
+	table.SetBorder(false)
+	table.SetAutoWrapText(false)
+	var total int64
+	for _, item := range items {
+		table.Append([]string{item.Kind, item.Name, FormatSize(item.Size), item.Modified.Format("2006-01-02 15:04")})
+		total += item.Size
+	}
+	table.Render()
+	noun := "items"
+	if len(items) == 1 {
+		noun = "item"
+	}
+	fmt.Fprintf(stringBuilder, "\n%d %s, %s in total\n", len(items), noun, FormatSize(total))
+	return stringBuilder.String()
+}
+
+// FormatSize formats a number of bytes for display, e.g. "1.5MB"
+func FormatSize(size int64) string {
+	return units.HumanSize(float64(size))
+}
+
+func (p *Prm) imageItems(cleaner BackendCleanerI, options CleanOptions) ([]CleanItem, error) {
+	images, err := cleaner.ToolImages()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list images: %s", err)
+	}
+
+	var installed []string
+	if options.Unused {
+		for _, tool := range p.readToolConfigs(options.ToolPath, false) {
+			installed = append(installed, fmt.Sprintf("_%s-%s_%s", tool.Plugin.Author, tool.Plugin.Id, tool.Plugin.Version))
+		}
+	}
+
+	var items []CleanItem
+	for _, image := range images {
+		if options.Unused && isImageOfInstalledTool(image.Name, installed) {
+			continue
+		}
+		id := image.ID
+		items = append(items, CleanItem{Kind: CleanKindImage, Name: image.Name, Size: image.Size, Modified: image.Created, remove: func() error {
+			return cleaner.RemoveImage(id)
+		}})
+	}
+	return items, nil
+}
+
+func isImageOfInstalledTool(imageName string, installedSuffixes []string) bool {
+	for _, suffix := range installedSuffixes {
+		if strings.HasSuffix(imageName, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Containers still running belong to a PRM that is still running, so are left alone
+func containerItems(cleaner BackendCleanerI) ([]CleanItem, error) {
+	containers, err := cleaner.ToolContainers()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list containers: %s", err)
+	}
+
+	var items []CleanItem
+	for _, c := range containers {
+		if c.Running {
+			continue
+		}
+		id := c.ID
+		items = append(items, CleanItem{Kind: CleanKindContainer, Name: c.Name, Size: c.Size, Modified: c.Created, remove: func() error {
+			return cleaner.RemoveContainer(id)
+		}})
+	}
+	return items, nil
+}
+
+// The Dockerfiles written to the tools' directories to build their images
+func (p *Prm) buildFileItems(toolPath string) []CleanItem {
+	var items []CleanItem
+	for _, tool := range p.readToolConfigs(toolPath, false) {
+		file := filepath.Join(tool.Path, generatedDockerfile)
+		if item, ok := p.fileItem(CleanKindBuildFile, file); ok {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Each entry of the cache directory, and each cached validation result
+func (p *Prm) cacheItems() ([]CleanItem, error) {
+	if p.CacheDir == "" {
+		return nil, nil
+	}
+	entries, err := p.AFS.ReadDir(p.CacheDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to read the cache directory %s: %s", p.CacheDir, err)
+	}
+
+	resultsDir := filepath.Join(p.CacheDir, resultCacheDirName)
+	var items []CleanItem
+	for _, entry := range entries {
+		path := filepath.Join(p.CacheDir, entry.Name())
+		if path == resultsDir && entry.IsDir() {
+			results, err := p.AFS.ReadDir(resultsDir)
+			if err != nil {
+				return nil, err
+			}
+			for _, result := range results {
+				resultPath := filepath.Join(resultsDir, result.Name())
+				if item, ok := p.fileItem(CleanKindCache, resultPath); ok {
+					items = append(items, item)
+				}
+			}
+			continue
+		}
+		if item, ok := p.fileItem(CleanKindCache, path); ok {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// The logs and reports 'prm validate' wrote to the codedir
+func (p *Prm) logItems() ([]CleanItem, error) {
+	outputDir := filepath.Join(p.CodeDir, ".prm-validate")
+	var items []CleanItem
+	err := p.AFS.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return filepath.SkipDir
+		}
+		if err != nil || info.IsDir() {
+			return err
+		}
+		if item, ok := p.fileItem(CleanKindLog, path); ok {
+			items = append(items, item)
+		}
+		return nil
+	})
+	return items, err
+}
+
+// An item for a file or directory, with the total size and latest
+// modification time of the files in it
+func (p *Prm) fileItem(kind string, path string) (CleanItem, bool) {
+	item := CleanItem{Kind: kind, Name: path}
+	var dirModified time.Time
+	err := p.AFS.Walk(path, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if file == path {
+				dirModified = info.ModTime()
+			}
+			return nil
+		}
+		item.Size += info.Size()
+		if info.ModTime().After(item.Modified) {
+			item.Modified = info.ModTime()
+		}
+		return nil
+	})
+	if err != nil {
+		return item, false
+	}
+	// an empty directory
+	if item.Modified.IsZero() {
+		item.Modified = dirModified
+	}
+	item.remove = func() error {
+		return p.AFS.RemoveAll(path)
+	}
+	return item, true
+}
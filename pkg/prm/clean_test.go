@@ -0,0 +1,129 @@
+package prm_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/puppetlabs/prm/internal/pkg/mock"
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrm_Clean(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-60 * 24 * time.Hour)
+
+	stub := func(t *testing.T) (*prm.Prm, *mock.DockerClient) {
+		p := stubInstalledTools(t, "puppetlabs lint 0.2.0")
+		p.CodeDir = "code"
+		p.CacheDir = "cache"
+		files := map[string]time.Time{
+			filepath.Join(installedToolsPath, "puppetlabs/lint/0.2.0/generated.Dockerfile"): now,
+			"cache/gems/rubocop.gem":            old,
+			"cache/validate/abc123.json":        old,
+			"cache/validate/def456.json":        now,
+			"code/.prm-validate/lint_2022.log":  old,
+			"code/.prm-validate/ci/report.json": now,
+			"code/manifests/init.pp":            old,
+		}
+		for file, modified := range files {
+			assert.NoError(t, p.AFS.WriteFile(file, []byte("content"), 0644))
+			assert.NoError(t, p.AFS.Chtimes(file, modified, modified))
+		}
+
+		client := &mock.DockerClient{
+			ImagesSlice: []types.ImageSummary{
+				{ID: "installed", RepoTags: []string{"pdk:puppet-7.15.0_puppetlabs-lint_0.2.0"}, Size: 1000, Created: now.Unix()},
+				{ID: "uninstalled", RepoTags: []string{"localhost/pdk:puppet-7.15.0_puppetlabs-lint_0.1.0"}, Size: 2000, Created: old.Unix()},
+				{ID: "other", RepoTags: []string{"ruby:3.1"}, Size: 4000},
+			},
+			ContainersSlice: []types.Container{
+				{ID: "orphaned", Labels: map[string]string{prm.ContainerLabel: "puppetlabs/lint"}, State: "exited", SizeRw: 10, Created: old.Unix()},
+				{ID: "running", Labels: map[string]string{prm.ContainerLabel: "puppetlabs/lint"}, State: "running", SizeRw: 20},
+			},
+		}
+		p.Backend = &prm.Docker{Client: client, AFS: p.AFS}
+		return p, client
+	}
+
+	tests := []struct {
+		name      string
+		options   prm.CleanOptions
+		wantItems []string
+	}{
+		{
+			name:    "Everything is selected without a selector",
+			options: prm.CleanOptions{},
+			wantItems: []string{
+				"image pdk:puppet-7.15.0_puppetlabs-lint_0.2.0",
+				"image pdk:puppet-7.15.0_puppetlabs-lint_0.1.0",
+				"build file " + filepath.Join(installedToolsPath, "puppetlabs/lint/0.2.0/generated.Dockerfile"),
+				"container orphaned (puppetlabs/lint)",
+				"cache cache/gems",
+				"cache cache/validate/abc123.json",
+				"cache cache/validate/def456.json",
+				"log code/.prm-validate/ci/report.json",
+				"log code/.prm-validate/lint_2022.log",
+			},
+		},
+		{
+			name:      "Only images of tools that are no longer installed",
+			options:   prm.CleanOptions{Images: true, Unused: true},
+			wantItems: []string{"image pdk:puppet-7.15.0_puppetlabs-lint_0.1.0", "build file " + filepath.Join(installedToolsPath, "puppetlabs/lint/0.2.0/generated.Dockerfile")},
+		},
+		{
+			name:      "Unused selects only images without another selector",
+			options:   prm.CleanOptions{Unused: true},
+			wantItems: []string{"image pdk:puppet-7.15.0_puppetlabs-lint_0.1.0", "build file " + filepath.Join(installedToolsPath, "puppetlabs/lint/0.2.0/generated.Dockerfile")},
+		},
+		{
+			name:      "Only items older than the given age",
+			options:   prm.CleanOptions{Cache: true, Logs: true, OlderThan: 30 * 24 * time.Hour},
+			wantItems: []string{"cache cache/gems", "cache cache/validate/abc123.json", "log code/.prm-validate/lint_2022.log"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, client := stub(t)
+			tt.options.ToolPath = installedToolsPath
+
+			items, err := p.FindCleanItems(tt.options)
+			assert.NoError(t, err)
+			var got []string
+			var size int64
+			for _, item := range items {
+				got = append(got, item.Kind+" "+item.Name)
+				size += item.Size
+			}
+			assert.Equal(t, tt.wantItems, got)
+
+			freed, err := p.Clean(items)
+			assert.NoError(t, err)
+			assert.Equal(t, size, freed)
+			for _, item := range items {
+				switch item.Kind {
+				case prm.CleanKindImage, prm.CleanKindContainer:
+				default:
+					exists, _ := p.AFS.Exists(item.Name)
+					assert.False(t, exists, "%s was not removed", item.Name)
+				}
+			}
+			assert.Equal(t, len(filterKind(items, prm.CleanKindImage)), len(client.RemovedImages))
+			assert.Equal(t, len(filterKind(items, prm.CleanKindContainer)), len(client.RemovedContainers))
+
+			exists, _ := p.AFS.Exists("code/manifests/init.pp")
+			assert.True(t, exists)
+		})
+	}
+}
+
+func filterKind(items []prm.CleanItem, kind string) (filtered []prm.CleanItem) {
+	for _, item := range items {
+		if item.Kind == kind {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
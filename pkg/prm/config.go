@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/Masterminds/semver"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
 )
 
 const (
@@ -21,8 +23,21 @@ const (
 	ToolPathCfgKey     string      = "toolpath"
 	ToolTimeoutCfgKey  string      = "toolTimeout"
 	DefaultToolTimeout int         = 1800 // 30 minutes
+
+	ProjectConfigFileName string = ".prm.yaml"
+	OriginDefault         string = "default"
 )
 
+// The settings which can be set in the global and project config files
+var ConfigKeys = []string{PuppetVerCfgKey, BackendCfgKey, ToolPathCfgKey, ToolTimeoutCfgKey}
+
+// ConfigSetting is an effective setting and where its value came from
+type ConfigSetting struct {
+	Key    string
+	Value  string
+	Origin string
+}
+
 type Config struct {
 	PuppetVersion *semver.Version
 	Backend       BackendType
@@ -54,6 +69,14 @@ func (p *Prm) GenerateDefaultCfg() {
 }
 
 func (p *Prm) LoadConfig() error {
+	// Project config files are layered over the global config, with the
+	// file closest to the codedir taking precedence. Flags and environment
+	// variables still take precedence over both.
+	err := p.mergeProjectConfig()
+	if err != nil {
+		return err
+	}
+
 	// If the scenario where any other config value has been set AND the Puppet version is unset, a '{}' is written
 	// to the config file on disk. This causes issues when attempting to call semver.NewVersion.
 	puppetVer := viper.GetString(PuppetVerCfgKey)
@@ -67,6 +90,19 @@ func (p *Prm) LoadConfig() error {
 
 	p.RunningConfig.PuppetVersion = pupperSemVer
 
+	// Unless the user has chosen a Puppet version, use one that
+	// satisfies the module's requirements
+	if !isSetExplicitly(PuppetVerCfgKey) {
+		inferredVer, reason, err := p.getPuppetVersion()
+		if err != nil {
+			log.Warn().Msgf("Using Puppet %s: %s", pupperSemVer, err)
+		} else if inferredVer != nil {
+			// Logged at debug, as every command loads the config and some print machine-readable output
+			log.Debug().Msgf("Using Puppet %s as %s. Use 'prm set puppet' to override it", inferredVer, reason)
+			p.RunningConfig.PuppetVersion = inferredVer
+		}
+	}
+
 	// Load Backend from config
 	p.RunningConfig.Backend = BackendType(viper.GetString(BackendCfgKey))
 
@@ -89,3 +125,140 @@ func (p *Prm) GetDefaultToolPath() (string, error) {
 	log.Trace().Msgf("Default tool config path: %v", defaultToolPath)
 	return defaultToolPath, nil
 }
+
+// Finds the project config files that apply to the codedir, searching the codedir
+// and its parents up to the root of the git repository it is in. The files are
+// returned outermost first, i.e. lowest precedence first.
+func (p *Prm) findProjectConfigFiles() []string {
+	if p.AFS == nil {
+		return nil
+	}
+
+	codeDir, err := filepath.Abs(p.codeDirOrWorkingDir())
+	if err != nil {
+		return nil
+	}
+
+	var dirs []string
+	gitRootFound := false
+	for dir := codeDir; ; dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+		if exists, _ := p.AFS.Exists(filepath.Join(dir, ".git")); exists {
+			gitRootFound = true
+			break
+		}
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+	// Outside of a git repository only the codedir itself is searched
+	if !gitRootFound {
+		dirs = dirs[:1]
+	}
+
+	globalConfig, _ := filepath.Abs(viper.ConfigFileUsed())
+	var files []string
+	for i := len(dirs) - 1; i >= 0; i-- {
+		file := filepath.Join(dirs[i], ProjectConfigFileName)
+		if file == globalConfig {
+			continue
+		}
+		if exists, _ := p.AFS.Exists(file); exists {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+func (p *Prm) readConfigFile(file string) (map[string]interface{}, error) {
+	content, err := p.AFS.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := map[string]interface{}{}
+	err = yaml.Unmarshal(content, &settings)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse project config '%s': %s", file, err)
+	}
+	return settings, nil
+}
+
+func (p *Prm) mergeProjectConfig() error {
+	for _, file := range p.findProjectConfigFiles() {
+		settings, err := p.readConfigFile(file)
+		if err != nil {
+			return err
+		}
+
+		log.Trace().Msgf("Using project config file: %s", file)
+		err = viper.MergeConfigMap(settings)
+		if err != nil {
+			return fmt.Errorf("could not merge project config '%s': %s", file, err)
+		}
+	}
+	return nil
+}
+
+// GetConfigSettings returns each effective setting along with the
+// environment variable or config file it came from
+func (p *Prm) GetConfigSettings() ([]ConfigSetting, error) {
+	projectFiles := p.findProjectConfigFiles()
+	// innermost file first, as it takes precedence
+	var projectSettings []map[string]interface{}
+	for i := len(projectFiles) - 1; i >= 0; i-- {
+		settings, err := p.readConfigFile(projectFiles[i])
+		if err != nil {
+			return nil, err
+		}
+		projectSettings = append(projectSettings, settings)
+	}
+
+	global := viper.New()
+	if globalFile := viper.ConfigFileUsed(); globalFile != "" {
+		global.SetConfigFile(globalFile)
+		global.SetConfigType("yaml")
+		_ = global.ReadInConfig() // nolint:errcheck // an unreadable global config just contributes no settings
+	}
+
+	var settings []ConfigSetting
+	for _, key := range ConfigKeys {
+		setting := ConfigSetting{Key: key, Value: viper.GetString(key), Origin: OriginDefault}
+
+		if envVar := strings.ToUpper(key); os.Getenv(envVar) != "" {
+			setting.Origin = fmt.Sprintf("environment variable %s", envVar)
+		} else if file := projectFileSetting(key, projectFiles, projectSettings); file != "" {
+			setting.Origin = file
+		} else if global.IsSet(key) && global.GetString(key) != "" {
+			setting.Origin = viper.ConfigFileUsed()
+		} else if key == PuppetVerCfgKey {
+			if inferredVer, _, err := p.getPuppetVersion(); err == nil && inferredVer != nil {
+				setting.Value = inferredVer.String()
+				setting.Origin = filepath.Join(p.codeDirOrWorkingDir(), MetadataFileName)
+			}
+		}
+
+		settings = append(settings, setting)
+	}
+	return settings, nil
+}
+
+func projectFileSetting(key string, files []string, settings []map[string]interface{}) string {
+	for i, fileSettings := range settings {
+		for k := range fileSettings {
+			if strings.EqualFold(k, key) {
+				return files[len(files)-1-i]
+			}
+		}
+	}
+	return ""
+}
+
+// Whether a setting was given by an environment variable or
+// config file rather than falling back to its default
+func isSetExplicitly(key string) bool {
+	if os.Getenv(strings.ToUpper(key)) != "" {
+		return true
+	}
+	return viper.InConfig(key) && viper.GetString(key) != ""
+}
@@ -3,8 +3,10 @@ package prm_test
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/spf13/afero"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
@@ -74,3 +76,39 @@ func TestLoadConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadConfig_ProjectConfig(t *testing.T) {
+	defer viper.Reset()
+
+	fs := afero.NewMemMapFs()
+	afs := &afero.Afero{Fs: fs}
+	afs.MkdirAll("/repo/.git", 0755)                                                                  //nolint:gosec,errcheck
+	afs.MkdirAll("/repo/module", 0755)                                                                //nolint:gosec,errcheck
+	afs.WriteFile("/.prm.yaml", []byte("backend: local\n"), 0644)                                     //nolint:gosec,errcheck
+	afs.WriteFile("/repo/.prm.yaml", []byte("backend: podman\npuppetversion: 6.0.0\n"), 0644)         //nolint:gosec,errcheck
+	afs.WriteFile("/repo/module/.prm.yaml", []byte("puppetversion: 6.27.0\ntoolTimeout: 60\n"), 0644) //nolint:gosec,errcheck
+
+	viper.Reset()
+	viper.AutomaticEnv()
+	prmObj := &prm.Prm{AFS: afs, CodeDir: "/repo/module"}
+	prmObj.GenerateDefaultCfg()
+	err := prmObj.LoadConfig()
+	assert.NoError(t, err)
+
+	assert.Equal(t, "6.27.0", prmObj.RunningConfig.PuppetVersion.String())
+	assert.Equal(t, prm.PODMAN, prmObj.RunningConfig.Backend)
+	assert.Equal(t, 60*time.Second, prmObj.RunningConfig.Timeout)
+
+	t.Setenv("TOOLTIMEOUT", "90")
+	settings, err := prmObj.GetConfigSettings()
+	assert.NoError(t, err)
+
+	origins := map[string]prm.ConfigSetting{}
+	for _, setting := range settings {
+		origins[setting.Key] = setting
+	}
+	assert.Equal(t, prm.ConfigSetting{Key: prm.PuppetVerCfgKey, Value: "6.27.0", Origin: "/repo/module/.prm.yaml"}, origins[prm.PuppetVerCfgKey])
+	assert.Equal(t, prm.ConfigSetting{Key: prm.BackendCfgKey, Value: "podman", Origin: "/repo/.prm.yaml"}, origins[prm.BackendCfgKey])
+	assert.Equal(t, prm.ConfigSetting{Key: prm.ToolTimeoutCfgKey, Value: "90", Origin: "environment variable TOOLTIMEOUT"}, origins[prm.ToolTimeoutCfgKey])
+	assert.Equal(t, prm.OriginDefault, origins[prm.ToolPathCfgKey].Origin)
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package prm
+
+import "golang.org/x/sys/unix"
+
+// The bytes free to the user on the filesystem that holds the path
+func freeDiskSpace(path string) (uint64, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil //nolint:unconvert // the field types differ between platforms
+}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -17,11 +18,13 @@ import (
 	"github.com/Masterminds/semver"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/api/types/network"
 	dockerClient "github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/archive"
 	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/go-units"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/afero"
@@ -38,6 +41,12 @@ type Docker struct {
 	AlwaysBuild    bool
 }
 
+const (
+	// Every container PRM starts is labelled with the tool it runs, so that
+	// containers orphaned by a crash can be found and removed
+	ContainerLabel = "prm"
+)
+
 var (
 	ErrDockerNotRunning = fmt.Errorf("docker is not running, please start the docker process")
 )
@@ -50,10 +59,12 @@ type DockerClientI interface {
 	ContainerStart(ctx context.Context, containerID string, options types.ContainerStartOptions) error
 	ContainerWait(ctx context.Context, containerID string, condition container.WaitCondition) (<-chan container.ContainerWaitOKBody, <-chan error)
 	ImageBuild(ctx context.Context, buildContext io.Reader, options types.ImageBuildOptions) (types.ImageBuildResponse, error)
+	ImagePull(ctx context.Context, refStr string, options types.ImagePullOptions) (io.ReadCloser, error)
 	ImageList(ctx context.Context, options types.ImageListOptions) ([]types.ImageSummary, error)
 	ImageRemove(ctx context.Context, imageID string, options types.ImageRemoveOptions) ([]types.ImageDeleteResponseItem, error)
 	ServerVersion(context.Context) (types.Version, error)
 	ContainerStop(ctx context.Context, containerID string, timeout *time.Duration) error
+	ContainerList(ctx context.Context, options types.ContainerListOptions) ([]types.Container, error)
 }
 
 func (d *Docker) GetTool(tool *Tool, prmConfig Config) error {
@@ -78,7 +89,7 @@ func (d *Docker) GetTool(tool *Tool, prmConfig Config) error {
 	foundImage := ""
 	for _, image := range list {
 		for _, tag := range image.RepoTags {
-			if tag == toolImageName {
+			if matchesImageTag(tag, toolImageName) {
 				log.Debug().Msgf("Found image: %s", image.ID)
 				if !d.AlwaysBuild {
 					return nil
@@ -92,6 +103,12 @@ func (d *Docker) GetTool(tool *Tool, prmConfig Config) error {
 		}
 	}
 
+	// Prebuilt images are pulled rather than built;
+	// alwaysBuild re-pulls them to pick up a moved tag
+	if tool.Cfg.Container != nil {
+		return d.pullImage(toolImageName)
+	}
+
 	if d.AlwaysBuild && foundImage != "" {
 		log.Info().Msg("Rebuilding image. Please wait...")
 		_, err = d.Client.ImageRemove(d.Context, foundImage, types.ImageRemoveOptions{Force: true})
@@ -171,6 +188,37 @@ func (d *Docker) GetTool(tool *Tool, prmConfig Config) error {
 	return nil
 }
 
+func (d *Docker) pullImage(imageName string) error {
+	log.Info().Msgf("Pulling image %s. Please wait...", imageName)
+	pullResponse, err := d.Client.ImagePull(d.Context, imageName, types.ImagePullOptions{})
+	if err != nil {
+		log.Error().Msgf("Unable to pull docker image %s", imageName)
+		return err
+	}
+
+	defer func() {
+		err = pullResponse.Close()
+		if err != nil {
+			log.Error().Msg(err.Error())
+		}
+	}()
+
+	// Parse the progress output from Docker, surfacing any pull errors
+	scanner := bufio.NewScanner(pullResponse)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		_ = json.Unmarshal(scanner.Bytes(), &line) // nolint:errcheck // we don't care about the error here
+		if pullErr, ok := line["error"]; ok {
+			return fmt.Errorf("unable to pull image %s: %v", imageName, pullErr)
+		}
+		if status, ok := line["status"]; ok {
+			log.Debug().Msgf("%v", status)
+		}
+	}
+
+	return nil
+}
+
 func (d *Docker) createDockerfile(tool *Tool, prmConfig Config) string {
 	// create a dockerfile from the Tool and prmConfig
 	dockerfile := strings.Builder{}
@@ -242,13 +290,156 @@ func (d *Docker) createDockerfile(tool *Tool, prmConfig Config) string {
 	return dockerfile.String()
 }
 
-// Creates a unique name for the image based on the tool and the PRM configuration
+// Creates a unique name for the image based on the tool and the PRM configuration;
+// tools that declare a prebuilt container image use that image as-is
 func (d *Docker) ImageName(tool *Tool, prmConfig Config) string {
+	if tool.Cfg.Container != nil {
+		tag := tool.Cfg.Container.Tag
+		if tag == "" {
+			tag = "latest"
+		}
+		return fmt.Sprintf("%s:%s", tool.Cfg.Container.Name, tag)
+	}
+
 	// build up a name based on the tool and puppet version
 	imageName := fmt.Sprintf("pdk:puppet-%s_%s-%s_%s", prmConfig.PuppetVersion.String(), tool.Cfg.Plugin.Author, tool.Cfg.Plugin.Id, tool.Cfg.Plugin.Version)
 	return imageName
 }
 
+// Podman qualifies locally built images with the "localhost/" registry,
+// so treat "localhost/pdk:..." as a match for "pdk:..."
+func matchesImageTag(tag string, imageName string) bool {
+	return strings.TrimPrefix(tag, "localhost/") == imageName
+}
+
+// ToolImage returns the name and ID of the image the tool runs in
+func (d *Docker) ToolImage(tool *Tool, prmConfig Config) (string, string, error) {
+	err := d.initClient()
+	if err != nil {
+		return "", "", err
+	}
+
+	imageName := d.ImageName(tool, prmConfig)
+	list, err := d.Client.ImageList(d.Context, types.ImageListOptions{})
+	if err != nil {
+		return "", "", err
+	}
+	for _, image := range list {
+		for _, tag := range image.RepoTags {
+			if matchesImageTag(tag, imageName) {
+				return imageName, image.ID, nil
+			}
+		}
+	}
+	return imageName, "", nil
+}
+
+// RemoveToolImages removes the images built for the tool, for any Puppet version
+func (d *Docker) RemoveToolImages(tool *Tool) ([]string, error) {
+	err := d.initClient()
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := d.Client.ImageList(d.Context, types.ImageListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	suffix := fmt.Sprintf("_%s-%s_%s", tool.Cfg.Plugin.Author, tool.Cfg.Plugin.Id, tool.Cfg.Plugin.Version)
+	var removed []string
+	for _, image := range list {
+		for _, tag := range image.RepoTags {
+			name := strings.TrimPrefix(tag, "localhost/")
+			if !strings.HasPrefix(name, "pdk:puppet-") || !strings.HasSuffix(name, suffix) {
+				continue
+			}
+			_, err = d.Client.ImageRemove(d.Context, image.ID, types.ImageRemoveOptions{Force: true})
+			if err != nil {
+				return removed, fmt.Errorf("unable to remove image %s: %s", tag, err)
+			}
+			removed = append(removed, tag)
+			break
+		}
+	}
+	return removed, nil
+}
+
+// ToolImages lists the images built for tools, for any Puppet version
+func (d *Docker) ToolImages() ([]BackendResource, error) {
+	err := d.initClient()
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := d.Client.ImageList(d.Context, types.ImageListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var images []BackendResource
+	for _, image := range list {
+		for _, tag := range image.RepoTags {
+			if name := strings.TrimPrefix(tag, "localhost/"); strings.HasPrefix(name, "pdk:puppet-") {
+				images = append(images, BackendResource{ID: image.ID, Name: name, Size: image.Size, Created: time.Unix(image.Created, 0)})
+				break
+			}
+		}
+	}
+	return images, nil
+}
+
+// ToolContainers lists the containers started to run tools, including stopped
+// containers left behind when PRM was killed before it could remove them
+func (d *Docker) ToolContainers() ([]BackendResource, error) {
+	err := d.initClient()
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := d.Client.ContainerList(d.Context, types.ContainerListOptions{
+		All:     true,
+		Size:    true,
+		Filters: filters.NewArgs(filters.Arg("label", ContainerLabel)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	containers := make([]BackendResource, 0, len(list))
+	for _, c := range list {
+		name := c.ID
+		if len(name) > 12 {
+			name = name[:12]
+		}
+		containers = append(containers, BackendResource{
+			ID:      c.ID,
+			Name:    fmt.Sprintf("%s (%s)", name, c.Labels[ContainerLabel]),
+			Size:    c.SizeRw,
+			Created: time.Unix(c.Created, 0),
+			Running: c.State == "running",
+		})
+	}
+	return containers, nil
+}
+
+func (d *Docker) RemoveImage(id string) error {
+	err := d.initClient()
+	if err != nil {
+		return err
+	}
+	_, err = d.Client.ImageRemove(d.Context, id, types.ImageRemoveOptions{Force: true})
+	return err
+}
+
+func (d *Docker) RemoveContainer(id string) error {
+	err := d.initClient()
+	if err != nil {
+		return err
+	}
+	return d.Client.ContainerRemove(d.Context, id, types.ContainerRemoveOptions{Force: true, RemoveVolumes: true})
+}
+
 func getOutputAsStrings(containerOutput *ContainerOutput, reader io.ReadCloser) error {
 	stdoutBuf := new(bytes.Buffer)
 	stderrBuf := new(bytes.Buffer)
@@ -263,22 +454,112 @@ func getOutputAsStrings(containerOutput *ContainerOutput, reader io.ReadCloser)
 	return nil
 }
 
-func (d *Docker) setTimeoutContext() (context.Context, context.CancelFunc) {
-	timeout := viper.GetInt("toolTimeout")
+// A timeout of zero uses the configured tool timeout
+func (d *Docker) setTimeoutContext(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
 	if timeout <= 0 {
-		timeout = 1800
+		seconds := viper.GetInt("toolTimeout")
+		if seconds <= 0 {
+			seconds = 1800
+		}
+		timeout = time.Duration(seconds) * time.Second
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	ctx, cancel := context.WithTimeout(parent, timeout)
 	return ctx, cancel
 }
 
-func (d *Docker) Validate(toolInfo ToolInfo, prmConfig Config, paths DirectoryPaths) (ValidateExitCode, string, error) {
+// Builds the container configuration used to run a tool
+func (d *Docker) containerConfig(tool *Tool, args []string, env map[string]string, prmConfig Config) container.Config {
+	containerConf := container.Config{
+		Image:  d.ImageName(tool, prmConfig),
+		Tty:    false,
+		Labels: map[string]string{ContainerLabel: fmt.Sprintf("%s/%s", tool.Cfg.Plugin.Author, tool.Cfg.Plugin.Id)},
+	}
+
+	// prebuilt images know nothing of the tool config, so supply
+	// what the generated Dockerfile would otherwise have baked in
+	if tool.Cfg.Container != nil {
+		containerConf.WorkingDir = "/code"
+		containerConf.Cmd = tool.Cfg.Common.DefaultArgs
+		if len(tool.Cfg.Common.Env) > 0 {
+			merged := make(map[string]string)
+			for key, val := range tool.Cfg.Common.Env {
+				merged[key] = val
+			}
+			// the env of this run overrides that of the tool
+			for key, val := range env {
+				merged[key] = val
+			}
+			env = merged
+		}
+	}
+
+	// args can override the default CMD
+	if len(args) > 0 {
+		containerConf.Cmd = args
+	}
+
+	for key, val := range env {
+		containerConf.Env = append(containerConf.Env, fmt.Sprintf("%s=%s", key, val))
+	}
+	sort.Strings(containerConf.Env)
+
+	return containerConf
+}
+
+// Works out the CPUs and memory a tool's container may use: those of
+// this run, else those of its prm-config.yml. Unset limits are unlimited.
+func (d *Docker) resources(toolInfo ToolInfo) (container.Resources, error) {
+	cpus := toolInfo.Cpus
+	if cpus == 0 {
+		cpus = toolInfo.Tool.Cfg.Common.Cpus
+	}
+	memory := toolInfo.Memory
+	if memory == "" {
+		memory = toolInfo.Tool.Cfg.Common.Memory
+	}
+
+	resources := container.Resources{}
+	if cpus < 0 {
+		return resources, fmt.Errorf("invalid cpus %v, must be positive", cpus)
+	}
+	resources.NanoCPUs = int64(cpus * 1e9)
+	if memory != "" {
+		bytes, err := units.RAMInBytes(memory)
+		if err != nil {
+			return resources, fmt.Errorf("invalid memory '%s': %s", memory, err)
+		}
+		resources.Memory = bytes
+	}
+	return resources, nil
+}
+
+// Builds the host configuration that mounts the code and cache directories
+func (d *Docker) hostConfig(codeDir string, cacheDir string, codeReadOnly bool, resources container.Resources) container.HostConfig {
+	return container.HostConfig{
+		Resources: resources,
+		Mounts: []mount.Mount{
+			{
+				Type:     mount.TypeBind,
+				Source:   codeDir,
+				Target:   "/code",
+				ReadOnly: codeReadOnly,
+			},
+			{
+				Type:   mount.TypeBind,
+				Source: cacheDir,
+				Target: "/cache",
+			},
+		},
+	}
+}
+
+func (d *Docker) Validate(ctx context.Context, toolInfo ToolInfo, prmConfig Config, paths DirectoryPaths) (ValidateExitCode, ToolOutput, error) {
 	// is Docker up and running?
 	status := d.Status()
 	if !status.IsAvailable {
 		log.Error().Msgf("Docker is not available")
-		return VALIDATION_ERROR, "", fmt.Errorf("%s", status.StatusMsg)
+		return VALIDATION_ERROR, ToolOutput{ExitCode: NoExitCode}, fmt.Errorf("%s", status.StatusMsg)
 	}
 
 	// clean up paths
@@ -288,57 +569,26 @@ func (d *Docker) Validate(toolInfo ToolInfo, prmConfig Config, paths DirectoryPa
 	log.Debug().Msgf("Cache path: %s", cacheDir)
 
 	// stand up a container
-	containerConf := container.Config{
-		Image: d.ImageName(toolInfo.Tool, prmConfig),
-		Tty:   false,
-	}
-
-	if len(toolInfo.Args) > 0 {
-		containerConf.Cmd = toolInfo.Args
+	containerConf := d.containerConfig(toolInfo.Tool, toolInfo.Args, toolInfo.Env, prmConfig)
+	resources, err := d.resources(toolInfo)
+	if err != nil {
+		return VALIDATION_ERROR, ToolOutput{ExitCode: NoExitCode}, err
 	}
+	hostConf := d.hostConfig(codeDir, cacheDir, toolInfo.Tool.Cfg.Common.CodeDirReadOnly(), resources)
 
-	timeoutCtx, cancelFunc := d.setTimeoutContext()
+	timeoutCtx, cancelFunc := d.setTimeoutContext(ctx, toolInfo.timeout())
 	defer cancelFunc()
-	resp, err := d.Client.ContainerCreate(timeoutCtx,
-		&containerConf,
-		&container.HostConfig{
-			Mounts: []mount.Mount{
-				{
-					Type:   mount.TypeBind,
-					Source: codeDir,
-					Target: "/code",
-				},
-				{
-					Type:   mount.TypeBind,
-					Source: cacheDir,
-					Target: "/cache",
-				},
-			},
-		}, nil, nil, "")
+	resp, err := d.Client.ContainerCreate(timeoutCtx, &containerConf, &hostConf, nil, nil, "")
 
 	if err != nil {
-		return VALIDATION_ERROR, "", err
+		return VALIDATION_ERROR, ToolOutput{ExitCode: NoExitCode}, err
 	}
 	// the autoremove functionality is too aggressive
 	// it fires before we can get at the logs
-	defer func() {
-		newContext := context.Background() // allows container to be removed after the tool times out
-		duration := time.Duration(0)
-		err := d.Client.ContainerStop(newContext, resp.ID, &duration)
-		if err != nil {
-			log.Error().Msgf("Error stopping container: %s", err)
-		}
-
-		err = d.Client.ContainerRemove(newContext, resp.ID, types.ContainerRemoveOptions{
-			RemoveVolumes: true,
-		})
-		if err != nil {
-			log.Error().Msgf("Error removing container: %s", err)
-		}
-	}()
+	defer d.stopAndRemoveContainer(resp.ID, time.Duration(0))
 
 	if err := d.Client.ContainerStart(timeoutCtx, resp.ID, types.ContainerStartOptions{}); err != nil {
-		return VALIDATION_ERROR, "", err
+		return VALIDATION_ERROR, ToolOutput{ExitCode: NoExitCode}, err
 	}
 
 	isError := make(chan error)
@@ -358,33 +608,34 @@ func (d *Docker) Validate(toolInfo ToolInfo, prmConfig Config, paths DirectoryPa
 	for {
 		out, err := d.Client.ContainerLogs(timeoutCtx, resp.ID, types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true, Tail: "all", Follow: true})
 		if err != nil {
-			return VALIDATION_ERROR, "", err
+			return VALIDATION_ERROR, ToolOutput{ExitCode: NoExitCode}, err
 		}
 
 		err = getOutputAsStrings(&containerOutput, out)
 		if err != nil {
-			return VALIDATION_ERROR, "", err
+			return VALIDATION_ERROR, ToolOutput{ExitCode: NoExitCode}, err
 		}
 
 		select {
 		case err := <-isError:
-			return VALIDATION_ERROR, containerOutput.stdout, err
+			return VALIDATION_ERROR, ToolOutput{Stdout: containerOutput.stdout, Stderr: containerOutput.stderr, ExitCode: NoExitCode}, err
 		case exitValues := <-toolExit:
+			output := ToolOutput{Stdout: containerOutput.stdout, Stderr: containerOutput.stderr, ExitCode: int(exitValues.StatusCode)}
 			if exitValues.StatusCode == int64(toolInfo.Tool.Cfg.Common.SuccessExitCode) {
-				return VALIDATION_PASS, containerOutput.stdout, nil
+				return VALIDATION_PASS, output, nil
 			} else {
 				if containerOutput.stderr != "" {
 					err = fmt.Errorf("%s", containerOutput.stderr)
 				} else {
 					err = fmt.Errorf("")
 				}
-				return VALIDATION_FAILED, containerOutput.stdout, err
+				return VALIDATION_FAILED, output, err
 			}
 		}
 	}
 }
 
-func (d *Docker) Exec(tool *Tool, args []string, prmConfig Config, paths DirectoryPaths) (ToolExitCode, error) {
+func (d *Docker) Exec(ctx context.Context, tool *Tool, args []string, prmConfig Config, paths DirectoryPaths) (ToolExitCode, error) {
 	// is Docker up and running?
 	status := d.Status()
 	if !status.IsAvailable {
@@ -401,54 +652,24 @@ func (d *Docker) Exec(tool *Tool, args []string, prmConfig Config, paths Directo
 	log.Info().Msgf("Additional Args: %v", args)
 
 	// stand up a container
-	containerConf := container.Config{
-		Image: d.ImageName(tool, prmConfig),
-		Tty:   false,
-	}
-	// args can override the default CMD
-	if len(args) > 0 {
-		containerConf.Cmd = args
+	containerConf := d.containerConfig(tool, args, nil, prmConfig)
+	toolInfo := ToolInfo{Tool: tool}
+	resources, err := d.resources(toolInfo)
+	if err != nil {
+		return FAILURE, err
 	}
+	hostConf := d.hostConfig(codeDir, cacheDir, tool.Cfg.Common.CodeDirReadOnly(), resources)
 
-	timeoutCtx, cancelFunc := d.setTimeoutContext()
+	timeoutCtx, cancelFunc := d.setTimeoutContext(ctx, toolInfo.timeout())
 	defer cancelFunc()
-	resp, err := d.Client.ContainerCreate(timeoutCtx,
-		&containerConf,
-		&container.HostConfig{
-			Mounts: []mount.Mount{
-				{
-					Type:   mount.TypeBind,
-					Source: codeDir,
-					Target: "/code",
-				},
-				{
-					Type:   mount.TypeBind,
-					Source: cacheDir,
-					Target: "/cache",
-				},
-			},
-		}, nil, nil, "")
+	resp, err := d.Client.ContainerCreate(timeoutCtx, &containerConf, &hostConf, nil, nil, "")
 
 	if err != nil {
 		return FAILURE, err
 	}
 	// the autoremove functionality is too aggressive
 	// it fires before we can get at the logs
-	defer func() {
-		newContext := context.Background() // allows container to be removed after the tool times out
-		duration := time.Duration(1)
-		err := d.Client.ContainerStop(newContext, resp.ID, &duration)
-		if err != nil {
-			log.Error().Msgf("Error stopping container: %s", err)
-		}
-
-		err = d.Client.ContainerRemove(newContext, resp.ID, types.ContainerRemoveOptions{
-			RemoveVolumes: true,
-		})
-		if err != nil {
-			log.Error().Msgf("Error removing container: %s", err)
-		}
-	}()
+	defer d.stopAndRemoveContainer(resp.ID, time.Duration(1))
 
 	if err := d.Client.ContainerStart(timeoutCtx, resp.ID, types.ContainerStartOptions{}); err != nil {
 		return FAILURE, err
@@ -499,6 +720,23 @@ func (d *Docker) Exec(tool *Tool, args []string, prmConfig Config, paths Directo
 	}
 }
 
+// Stops and removes a container the tool ran in. A new context is used, so that the
+// container is removed after the tool times out or is cancelled.
+func (d *Docker) stopAndRemoveContainer(id string, stopTimeout time.Duration) {
+	ctx := context.Background()
+	err := d.Client.ContainerStop(ctx, id, &stopTimeout)
+	if err != nil {
+		log.Error().Msgf("Error stopping container: %s", err)
+	}
+
+	err = d.Client.ContainerRemove(ctx, id, types.ContainerRemoveOptions{
+		RemoveVolumes: true,
+	})
+	if err != nil {
+		log.Error().Msgf("Error removing container: %s", err)
+	}
+}
+
 func (d *Docker) initClient() (err error) {
 	if d.Client == nil {
 		cli, err := dockerClient.NewClientWithOpts(dockerClient.FromEnv)
@@ -156,12 +156,14 @@ func TestDocker_Validate(t *testing.T) {
 		toolArgs      []string
 	}
 	tests := []struct {
-		name       string
-		fields     fields
-		args       args
-		want       prm.ValidateExitCode
-		wantErr    bool
-		wantStdout string
+		name         string
+		fields       fields
+		args         args
+		want         prm.ValidateExitCode
+		wantErr      bool
+		wantStdout   string
+		wantStderr   string
+		wantExitCode int
 	}{
 		{
 			name: "Fails as server version is invalid",
@@ -170,8 +172,9 @@ func TestDocker_Validate(t *testing.T) {
 					ErrorString: "Invalid server verison",
 				},
 			},
-			want:    prm.VALIDATION_ERROR,
-			wantErr: true,
+			want:         prm.VALIDATION_ERROR,
+			wantErr:      true,
+			wantExitCode: prm.NoExitCode,
 		},
 		{
 			name: "Tool successfully validates",
@@ -223,9 +226,11 @@ func TestDocker_Validate(t *testing.T) {
 				id:            "good-project",
 				version:       "0.1.0",
 			},
-			want:       prm.VALIDATION_FAILED,
-			wantErr:    true,
-			wantStdout: defaultStdoutText,
+			want:         prm.VALIDATION_FAILED,
+			wantErr:      true,
+			wantStdout:   defaultStdoutText,
+			wantStderr:   "Tool found 1 validation error",
+			wantExitCode: 1,
 		},
 		{
 			name: "Error occurs while trying to validate with a tool",
@@ -242,8 +247,9 @@ func TestDocker_Validate(t *testing.T) {
 				id:            "good-project",
 				version:       "0.1.0",
 			},
-			want:    prm.VALIDATION_ERROR,
-			wantErr: true,
+			want:         prm.VALIDATION_ERROR,
+			wantErr:      true,
+			wantExitCode: prm.NoExitCode,
 		},
 	}
 	for _, tt := range tests {
@@ -276,7 +282,7 @@ func TestDocker_Validate(t *testing.T) {
 
 			toolInfo := CreateToolInfo(tt.args.id, tt.args.author, tt.args.version, tt.args.toolArgs)
 
-			got, stdout, err := d.Validate(toolInfo, prmConfig, tt.args.paths)
+			got, output, err := d.Validate(context.Background(), toolInfo, prmConfig, tt.args.paths)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Docker.Validate() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -284,9 +290,11 @@ func TestDocker_Validate(t *testing.T) {
 			if got != tt.want {
 				t.Errorf("Docker.Validate() = %v, want %v", got, tt.want)
 			}
-			if stdout != tt.wantStdout {
-				t.Errorf("Docker.Validate() = %v, want %v", stdout, tt.wantStdout)
+			if output.Stdout != tt.wantStdout {
+				t.Errorf("Docker.Validate() = %v, want %v", output.Stdout, tt.wantStdout)
 			}
+			assert.Equal(t, tt.wantStderr, output.Stderr)
+			assert.Equal(t, tt.wantExitCode, output.ExitCode)
 		})
 	}
 }
@@ -309,3 +317,236 @@ func CreateToolInfo(id, author, version string, args []string) prm.ToolInfo {
 		Args: args,
 	}
 }
+
+func TestDocker_GetTool_Container(t *testing.T) {
+	tests := []struct {
+		name        string
+		mockClient  mock.DockerClient
+		container   prm.ContainerConfig
+		alwaysBuild bool
+		wantPulled  string
+		errorMsg    string
+	}{
+		{
+			name:       "Prebuilt image not found locally is pulled",
+			container:  prm.ContainerConfig{Name: "example.com/toolchain", Tag: "1.2.3"},
+			wantPulled: "example.com/toolchain:1.2.3",
+		},
+		{
+			name:       "Prebuilt image without a tag pulls latest",
+			container:  prm.ContainerConfig{Name: "example.com/toolchain"},
+			wantPulled: "example.com/toolchain:latest",
+		},
+		{
+			name:      "Prebuilt image found locally is used as-is",
+			container: prm.ContainerConfig{Name: "example.com/toolchain", Tag: "1.2.3"},
+			mockClient: mock.DockerClient{
+				ImagesSlice: []types.ImageSummary{
+					{
+						RepoTags: []string{"example.com/toolchain:1.2.3"},
+						ID:       "foo",
+					},
+				},
+			},
+		},
+		{
+			name:      "Prebuilt image found locally is re-pulled with alwaysBuild",
+			container: prm.ContainerConfig{Name: "example.com/toolchain", Tag: "1.2.3"},
+			mockClient: mock.DockerClient{
+				ImagesSlice: []types.ImageSummary{
+					{
+						RepoTags: []string{"example.com/toolchain:1.2.3"},
+						ID:       "foo",
+					},
+				},
+			},
+			alwaysBuild: true,
+			wantPulled:  "example.com/toolchain:1.2.3",
+		},
+		{
+			name:      "Pull errors are reported",
+			container: prm.ContainerConfig{Name: "example.com/missing", Tag: "1.2.3"},
+			mockClient: mock.DockerClient{
+				PullOutput: `{"error":"manifest unknown"}`,
+			},
+			wantPulled: "example.com/missing:1.2.3",
+			errorMsg:   "unable to pull image example.com/missing:1.2.3: manifest unknown",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := CreateToolInfo("test", "user", "0.1.0", nil).Tool
+			tool.Cfg.Container = &tt.container
+
+			afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+			d := &prm.Docker{Client: &tt.mockClient, AFS: afs, AlwaysBuild: tt.alwaysBuild}
+			err := d.GetTool(tool, prm.Config{PuppetVersion: semver.MustParse("7.15.0")})
+			if tt.errorMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.errorMsg)
+			}
+			assert.Equal(t, tt.wantPulled, tt.mockClient.PulledImage)
+			assert.False(t, tt.mockClient.Built, "prebuilt images should never be built")
+		})
+	}
+}
+
+func TestDocker_Validate_CodeDirMount(t *testing.T) {
+	readOnly := false
+	writable := true
+	tests := []struct {
+		name             string
+		needsWriteAccess *bool
+		wantReadOnly     bool
+	}{
+		{
+			name:             "Tool that declares it does not need write access",
+			needsWriteAccess: &readOnly,
+			wantReadOnly:     true,
+		},
+		{
+			name:             "Tool that declares it needs write access",
+			needsWriteAccess: &writable,
+		},
+		{
+			name: "Tool that does not declare write access",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mock.DockerClient{}
+			d := &prm.Docker{Client: client}
+			toolInfo := CreateToolInfo("test", "user", "0.1.0", nil)
+			toolInfo.Tool.Cfg.Common.NeedsWriteAccess = tt.needsWriteAccess
+
+			_, _, err := d.Validate(context.Background(), toolInfo, prm.Config{PuppetVersion: semver.MustParse("7.15.0")}, prm.DirectoryPaths{})
+			assert.NoError(t, err)
+			assert.Equal(t, "/code", client.CreatedHostConfig.Mounts[0].Target)
+			assert.Equal(t, tt.wantReadOnly, client.CreatedHostConfig.Mounts[0].ReadOnly)
+			assert.False(t, client.CreatedHostConfig.Mounts[1].ReadOnly)
+		})
+	}
+}
+
+func TestDocker_Validate_Env(t *testing.T) {
+	client := &mock.DockerClient{}
+	d := &prm.Docker{Client: client}
+	toolInfo := CreateToolInfo("test", "user", "0.1.0", nil)
+	toolInfo.Env = map[string]string{"SPEC_OPTS": "--fail-fast", "LANG": "C"}
+
+	_, _, err := d.Validate(context.Background(), toolInfo, prm.Config{PuppetVersion: semver.MustParse("7.15.0")}, prm.DirectoryPaths{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"LANG=C", "SPEC_OPTS=--fail-fast"}, client.CreatedConfig.Env)
+}
+
+func TestDocker_Validate_ContainerEnv(t *testing.T) {
+	client := &mock.DockerClient{}
+	d := &prm.Docker{Client: client}
+	toolInfo := CreateToolInfo("test", "user", "0.1.0", nil)
+	toolInfo.Tool.Cfg.Container = &prm.ContainerConfig{Name: "example.com/toolchain", Tag: "1.2.3"}
+	toolInfo.Tool.Cfg.Common.Env = map[string]string{"LANG": "en_US.UTF-8", "CONFIG_FILE": "/code/config.yaml"}
+	toolInfo.Env = map[string]string{"LANG": "C", "SPEC_OPTS": "--fail-fast"}
+
+	_, _, err := d.Validate(context.Background(), toolInfo, prm.Config{PuppetVersion: semver.MustParse("7.15.0")}, prm.DirectoryPaths{})
+	assert.NoError(t, err)
+	// The image was not built from the tool config, so its env is set on the container
+	assert.Equal(t, []string{"CONFIG_FILE=/code/config.yaml", "LANG=C", "SPEC_OPTS=--fail-fast"}, client.CreatedConfig.Env)
+}
+
+func TestDocker_Validate_Resources(t *testing.T) {
+	tests := []struct {
+		name          string
+		common        prm.CommonConfig
+		cpus          float64
+		memory        string
+		timeout       time.Duration
+		wantNanoCPUs  int64
+		wantMemory    int64
+		wantTimeout   time.Duration
+		wantErrString string
+	}{
+		{
+			name:        "Should not limit a tool without limits",
+			wantTimeout: time.Duration(prm.DefaultToolTimeout) * time.Second,
+		},
+		{
+			name:         "Should apply the limits of the tool's prm-config.yml",
+			common:       prm.CommonConfig{Cpus: 2, Memory: "1g", Timeout: 600},
+			wantNanoCPUs: 2000000000,
+			wantMemory:   1024 * 1024 * 1024,
+			wantTimeout:  600 * time.Second,
+		},
+		{
+			name:         "Should prefer the limits set for the run of the tool",
+			common:       prm.CommonConfig{Cpus: 2, Memory: "1g", Timeout: 600},
+			cpus:         1.5,
+			memory:       "512m",
+			timeout:      60 * time.Second,
+			wantNanoCPUs: 1500000000,
+			wantMemory:   512 * 1024 * 1024,
+			wantTimeout:  60 * time.Second,
+		},
+		{
+			name:          "Should error for an invalid memory limit",
+			memory:        "lots",
+			wantErrString: "invalid memory 'lots'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mock.DockerClient{}
+			d := &prm.Docker{Client: client}
+			toolInfo := CreateToolInfo("test", "user", "0.1.0", nil)
+			toolInfo.Tool.Cfg.Common = tt.common
+			toolInfo.Cpus = tt.cpus
+			toolInfo.Memory = tt.memory
+			toolInfo.Timeout = tt.timeout
+
+			got, _, err := d.Validate(context.Background(), toolInfo, prm.Config{PuppetVersion: semver.MustParse("7.15.0")}, prm.DirectoryPaths{})
+			if tt.wantErrString != "" {
+				assert.ErrorContains(t, err, tt.wantErrString)
+				assert.Equal(t, prm.VALIDATION_ERROR, got)
+				assert.Nil(t, client.CreatedHostConfig)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantNanoCPUs, client.CreatedHostConfig.NanoCPUs)
+			assert.Equal(t, tt.wantMemory, client.CreatedHostConfig.Memory)
+			assert.InDelta(t, tt.wantTimeout.Seconds(), client.CreatedTimeout.Seconds(), 5)
+		})
+	}
+}
+
+func TestDocker_Cancelled(t *testing.T) {
+	toolInfo := CreateToolInfo("test", "user", "0.1.0", nil)
+	config := prm.Config{PuppetVersion: semver.MustParse("7.15.0")}
+
+	t.Run("Validate stops and removes the container", func(t *testing.T) {
+		client := &mock.DockerClient{}
+		d := &prm.Docker{Client: client}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		got, _, err := d.Validate(ctx, toolInfo, config, prm.DirectoryPaths{})
+		assert.Error(t, err)
+		assert.Equal(t, prm.VALIDATION_ERROR, got)
+		assert.Equal(t, "user/test", client.CreatedConfig.Labels[prm.ContainerLabel])
+		assert.Equal(t, []string{mock.ContainerID}, client.StoppedContainers)
+		assert.Equal(t, []string{mock.ContainerID}, client.RemovedContainers)
+	})
+
+	t.Run("Exec stops and removes the container", func(t *testing.T) {
+		client := &mock.DockerClient{}
+		d := &prm.Docker{Client: client}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		got, err := d.Exec(ctx, toolInfo.Tool, nil, config, prm.DirectoryPaths{})
+		assert.Error(t, err)
+		assert.Equal(t, prm.FAILURE, got)
+		assert.Equal(t, "user/test", client.CreatedConfig.Labels[prm.ContainerLabel])
+		assert.Equal(t, []string{mock.ContainerID}, client.StoppedContainers)
+		assert.Equal(t, []string{mock.ContainerID}, client.RemovedContainers)
+	})
+}
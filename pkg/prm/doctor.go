@@ -0,0 +1,292 @@
+package prm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+const (
+	CheckPass = "pass"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+
+	// Validation builds an image per tool and Puppet version, so warn before space runs out
+	diskSpaceWarnBytes = 5 * 1024 * 1024 * 1024
+	diskSpaceFailBytes = 1024 * 1024 * 1024
+)
+
+// DoctorCheck is the result of one check of the environment PRM runs in
+type DoctorCheck struct {
+	Name    string `json:"name"`
+	Result  string `json:"result"`
+	Message string `json:"message"`
+	// How to fix a check that did not pass
+	Hint string `json:"hint,omitempty"`
+}
+
+// Doctor checks that the backend, tool path, validate.yml, codedir, cachedir,
+// Puppet version and disk space are fit to run tools with
+func (p *Prm) Doctor(toolPath string) []DoctorCheck {
+	toolPathCheck, toolsFound := p.checkToolPath(toolPath)
+	return []DoctorCheck{
+		p.checkBackend(),
+		toolPathCheck,
+		p.checkValidateFile(toolPath, toolsFound),
+		p.checkWritable("Code directory", p.CodeDir, "Use --codedir to select a directory you can write to"),
+		p.checkCacheDir(),
+		p.checkPuppetVersion(),
+		p.checkDiskSpace(),
+	}
+}
+
+// DoctorFailures counts the checks that failed
+func DoctorFailures(checks []DoctorCheck) (count int) {
+	for _, check := range checks {
+		if check.Result == CheckFail {
+			count++
+		}
+	}
+	return count
+}
+
+// FormatDoctorChecks formats the checks to display on the console in table or json format
+func FormatDoctorChecks(checks []DoctorCheck, outputType string) (string, error) {
+	switch outputType {
+	case "json":
+		content, err := json.MarshalIndent(checks, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(content) + "\n", nil
+	case "table":
+		var lines strings.Builder
+		for _, check := range checks {
+			lines.WriteString(fmt.Sprintf("[%s] %s: %s\n", check.Result, check.Name, check.Message))
+			if check.Hint != "" {
+				lines.WriteString(fmt.Sprintf("       Fix: %s\n", check.Hint))
+			}
+		}
+		return lines.String(), nil
+	}
+	return "", fmt.Errorf("unknown format '%s', must be one of [table|json]", outputType)
+}
+
+func (p *Prm) checkBackend() DoctorCheck {
+	check := DoctorCheck{Name: "Backend"}
+	status := p.Backend.Status()
+	if status.IsAvailable {
+		check.Result = CheckPass
+		check.Message = fmt.Sprintf("%s is available", p.RunningConfig.Backend)
+		return check
+	}
+
+	check.Result = CheckFail
+	check.Message = fmt.Sprintf("%s is not available: %s", p.RunningConfig.Backend, status.StatusMsg)
+	switch p.RunningConfig.Backend {
+	case PODMAN:
+		check.Hint = "Start the podman socket service with 'systemctl --user start podman.socket'"
+	default:
+		check.Hint = "Start Docker, or choose another backend with 'prm set backend'"
+	}
+	return check
+}
+
+// Checks the tool path exists and every tool config in it parses;
+// returns whether any tools were found
+func (p *Prm) checkToolPath(toolPath string) (DoctorCheck, bool) {
+	check := DoctorCheck{Name: "Tool path"}
+	if exists, _ := p.AFS.DirExists(toolPath); !exists {
+		check.Result = CheckFail
+		check.Message = fmt.Sprintf("%s does not exist", toolPath)
+		check.Hint = "Install tools with 'prm install', or point to installed tools with 'prm set toolpath <path>' or --toolpath"
+		return check, false
+	}
+
+	files, _ := p.IOFS.Glob(toolPath + "/**/**/**/" + ToolConfigFileName)
+	var unparsable []string
+	for _, file := range files {
+		if tool := p.readToolConfig(file); tool.Cfg.Plugin == nil {
+			unparsable = append(unparsable, file)
+		}
+	}
+
+	switch {
+	case len(unparsable) > 0:
+		check.Result = CheckFail
+		check.Message = fmt.Sprintf("unable to parse %s", strings.Join(unparsable, ", "))
+		check.Hint = "Fix the files, or reinstall the tools with 'prm install --force'"
+	case len(files) == 0:
+		check.Result = CheckWarn
+		check.Message = fmt.Sprintf("no tools are installed in %s", toolPath)
+		check.Hint = "Install tools with 'prm install'"
+	default:
+		check.Result = CheckPass
+		check.Message = fmt.Sprintf("%d tool versions installed in %s", len(files), toolPath)
+	}
+	return check, len(files) > len(unparsable)
+}
+
+// Checks every tool in the groups of the codedir's validate.yml is installed
+func (p *Prm) checkValidateFile(toolPath string, toolsFound bool) DoctorCheck {
+	check := DoctorCheck{Name: "validate.yml"}
+	validateFile := filepath.Join(p.CodeDir, "validate.yml")
+	if exists, _ := p.AFS.Exists(validateFile); !exists {
+		check.Result = CheckPass
+		check.Message = "no validate.yml in the code directory"
+		return check
+	}
+
+	groups, err := p.getGroupsFromFile(validateFile)
+	if err != nil {
+		check.Result = CheckFail
+		check.Message = fmt.Sprintf("unable to parse %s: %s", validateFile, err)
+		check.Hint = "Fix the YAML in validate.yml"
+		return check
+	}
+
+	if toolsFound {
+		if err := p.List(toolPath, "", false); err != nil {
+			toolsFound = false
+		}
+	}
+
+	var missing []string
+	count := 0
+	for _, group := range groups {
+		resolved, err := resolveGroup(withBuiltinGroups(groups), group)
+		if err != nil {
+			check.Result = CheckFail
+			check.Message = fmt.Sprintf("group '%s': %s", group.ID, err)
+			check.Hint = "Correct the group in validate.yml, 'prm validate --group " + group.ID + " --explain' shows the tools it runs"
+			return check
+		}
+		for _, tool := range resolved.Tools {
+			count++
+			if !toolsFound {
+				missing = append(missing, tool.Name)
+				continue
+			}
+			if _, err := p.IsToolAvailable(tool.Name); err != nil {
+				missing = append(missing, tool.Name)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		check.Result = CheckFail
+		check.Message = fmt.Sprintf("tools are not installed: %s", strings.Join(missing, ", "))
+		check.Hint = "Install the tools with 'prm install', or correct their names in validate.yml"
+		return check
+	}
+
+	check.Result = CheckPass
+	check.Message = fmt.Sprintf("all %d tools in %d groups are installed", count, len(groups))
+	return check
+}
+
+// Checks a file can be created in the directory
+func (p *Prm) checkWritable(name string, dir string, hint string) DoctorCheck {
+	check := DoctorCheck{Name: name}
+	file, err := p.AFS.TempFile(dir, ".prm-doctor-")
+	if err != nil {
+		check.Result = CheckFail
+		check.Message = fmt.Sprintf("%s is not writable: %s", dir, err)
+		check.Hint = hint
+		return check
+	}
+	_ = file.Close()              // nolint:errcheck // the file is removed straight away
+	_ = p.AFS.Remove(file.Name()) // nolint:errcheck // a leftover empty file is harmless
+
+	check.Result = CheckPass
+	check.Message = fmt.Sprintf("%s is writable", dir)
+	return check
+}
+
+func (p *Prm) checkCacheDir() DoctorCheck {
+	if exists, _ := p.AFS.DirExists(p.CacheDir); !exists {
+		return DoctorCheck{
+			Name:    "Cache directory",
+			Result:  CheckWarn,
+			Message: fmt.Sprintf("%s does not exist", p.CacheDir),
+			Hint:    "It is created when a tool is first run; use --cachedir to select another directory if it cannot be",
+		}
+	}
+	return p.checkWritable("Cache directory", p.CacheDir, "Use --cachedir to select a directory you can write to")
+}
+
+// Checks there is a puppet/puppet-agent image for the Puppet version
+func (p *Prm) checkPuppetVersion() DoctorCheck {
+	check := DoctorCheck{Name: "Puppet version"}
+	version := p.RunningConfig.PuppetVersion
+	switch {
+	case version == nil:
+		check.Result = CheckFail
+		check.Message = "no Puppet version is configured"
+		check.Hint = fmt.Sprintf("Set a version with 'prm set puppet %s'", DefaultPuppetVer)
+	case p.RunningConfig.Backend == LOCAL:
+		check.Result = CheckPass
+		check.Message = fmt.Sprintf("%s; the local backend runs tools with the host's Puppet", version)
+	case isKnownPuppetVersion(version):
+		check.Result = CheckPass
+		check.Message = fmt.Sprintf("%s has a puppet/puppet-agent image", version)
+	case version.Major() < 5 || version.Major() > 7:
+		check.Result = CheckFail
+		check.Message = fmt.Sprintf("%s is not supported; tools run on Puppet 5, 6 or 7", version)
+		check.Hint = fmt.Sprintf("Set a supported version, e.g. 'prm set puppet %s'", DefaultPuppetVer)
+	default:
+		check.Result = CheckWarn
+		check.Message = fmt.Sprintf("%s may not have a puppet/puppet-agent image; images are known for %s", version, strings.Join(KnownPuppetVersions, ", "))
+		check.Hint = "If building a tool image fails, choose a known version with 'prm set puppet'"
+	}
+	return check
+}
+
+func isKnownPuppetVersion(version *semver.Version) bool {
+	for _, known := range KnownPuppetVersions {
+		if v, err := semver.NewVersion(known); err == nil && v.Equal(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks the space free on the filesystem holding the cache directory
+func (p *Prm) checkDiskSpace() DoctorCheck {
+	check := DoctorCheck{Name: "Disk space"}
+	dir := p.CacheDir
+	if dir == "" {
+		dir = "."
+	}
+	// the nearest directory that exists is on the same filesystem
+	for {
+		if _, err := os.Stat(dir); err == nil || filepath.Dir(dir) == dir {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+
+	free, err := freeDiskSpace(dir)
+	switch {
+	case err != nil:
+		check.Result = CheckWarn
+		check.Message = fmt.Sprintf("unable to read the space free for %s: %s", dir, err)
+	case free < diskSpaceFailBytes:
+		check.Result = CheckFail
+		check.Message = fmt.Sprintf("only %s free for %s", FormatSize(int64(free)), dir)
+		check.Hint = "Free space, e.g. with 'prm clean'"
+	case free < diskSpaceWarnBytes:
+		check.Result = CheckWarn
+		check.Message = fmt.Sprintf("only %s free for %s", FormatSize(int64(free)), dir)
+		check.Hint = "Tool images may not fit; free space, e.g. with 'prm clean'"
+	default:
+		check.Result = CheckPass
+		check.Message = fmt.Sprintf("%s free for %s", FormatSize(int64(free)), dir)
+	}
+	return check
+}
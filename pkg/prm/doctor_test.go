@@ -0,0 +1,129 @@
+package prm_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/puppetlabs/prm/internal/pkg/mock"
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrm_Doctor(t *testing.T) {
+	validateYml := "groups:\n  - id: ci\n    tools:\n      - name: puppetlabs/lint\n      - name: puppetlabs/epp\n"
+
+	tests := []struct {
+		name            string
+		tools           []string
+		toolPath        string
+		files           map[string]string
+		backendDown     bool
+		puppetVersion   string
+		want            map[string]string
+		wantMessageFrom map[string]string
+	}{
+		{
+			name:          "A healthy environment passes",
+			tools:         []string{"puppetlabs lint 0.1.0", "puppetlabs epp 0.1.0"},
+			files:         map[string]string{"code/validate.yml": validateYml, "cache/.keep": ""},
+			puppetVersion: prm.DefaultPuppetVer,
+			want: map[string]string{
+				"Backend":         prm.CheckPass,
+				"Tool path":       prm.CheckPass,
+				"validate.yml":    prm.CheckPass,
+				"Code directory":  prm.CheckPass,
+				"Cache directory": prm.CheckPass,
+				"Puppet version":  prm.CheckPass,
+			},
+		},
+		{
+			name:          "Tools in validate.yml must be installed",
+			tools:         []string{"puppetlabs lint 0.1.0"},
+			files:         map[string]string{"code/validate.yml": validateYml},
+			puppetVersion: "7.1.0",
+			want: map[string]string{
+				"validate.yml":    prm.CheckFail,
+				"Cache directory": prm.CheckWarn,
+				"Puppet version":  prm.CheckWarn,
+			},
+			wantMessageFrom: map[string]string{"validate.yml": "puppetlabs/epp"},
+		},
+		{
+			name:          "Unparsable tool configs and an unavailable backend fail",
+			tools:         []string{"puppetlabs lint 0.1.0"},
+			files:         map[string]string{installedToolsPath + "/puppetlabs/broken/0.1.0/prm-config.yml": "plugin: [\n"},
+			backendDown:   true,
+			puppetVersion: "8.0.0",
+			want: map[string]string{
+				"Backend":        prm.CheckFail,
+				"Tool path":      prm.CheckFail,
+				"validate.yml":   prm.CheckPass,
+				"Puppet version": prm.CheckFail,
+			},
+			wantMessageFrom: map[string]string{"Tool path": "puppetlabs/broken"},
+		},
+		{
+			name:     "A missing tool path fails",
+			toolPath: "missing/tools",
+			want:     map[string]string{"Tool path": prm.CheckFail, "Puppet version": prm.CheckFail},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := stubInstalledTools(t, tt.tools...)
+			p.CodeDir = "code"
+			p.CacheDir = "cache"
+			assert.NoError(t, p.AFS.MkdirAll("code", 0750))
+			for file, content := range tt.files {
+				assert.NoError(t, p.AFS.WriteFile(file, []byte(content), 0644))
+			}
+			p.Backend = &mock.MockBackend{StatusIsAvailable: !tt.backendDown}
+			p.RunningConfig.Backend = prm.DOCKER
+			if tt.puppetVersion != "" {
+				p.RunningConfig.PuppetVersion = semver.MustParse(tt.puppetVersion)
+			}
+			toolPath := tt.toolPath
+			if toolPath == "" {
+				toolPath = installedToolsPath
+			}
+
+			checks := p.Doctor(toolPath)
+			results := map[string]prm.DoctorCheck{}
+			for _, check := range checks {
+				results[check.Name] = check
+				if check.Result != prm.CheckPass {
+					assert.NotEmpty(t, check.Hint, "%s has no hint", check.Name)
+				}
+			}
+			assert.Contains(t, results, "Disk space")
+			for name, want := range tt.want {
+				assert.Equal(t, want, results[name].Result, "%s: %s", name, results[name].Message)
+			}
+			for name, want := range tt.wantMessageFrom {
+				assert.Contains(t, results[name].Message, want)
+			}
+		})
+	}
+}
+
+func TestFormatDoctorChecks(t *testing.T) {
+	checks := []prm.DoctorCheck{
+		{Name: "Backend", Result: prm.CheckPass, Message: "docker is available"},
+		{Name: "Tool path", Result: prm.CheckFail, Message: "tools does not exist", Hint: "Install tools with 'prm install'"},
+	}
+
+	table, err := prm.FormatDoctorChecks(checks, "table")
+	assert.NoError(t, err)
+	assert.Equal(t, "[pass] Backend: docker is available\n[fail] Tool path: tools does not exist\n       Fix: Install tools with 'prm install'\n", table)
+
+	content, err := prm.FormatDoctorChecks(checks, "json")
+	assert.NoError(t, err)
+	var parsed []prm.DoctorCheck
+	assert.NoError(t, json.Unmarshal([]byte(content), &parsed))
+	assert.Equal(t, checks, parsed)
+	assert.Equal(t, 1, prm.DoctorFailures(parsed))
+
+	_, err = prm.FormatDoctorChecks(checks, "yaml")
+	assert.Error(t, err)
+}
@@ -2,6 +2,9 @@
 package prm
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/rs/zerolog/log"
 )
 
@@ -14,9 +17,10 @@ const (
 )
 
 // Executes a tool with the given arguments, against the codeDir.
-func (p *Prm) Exec(tool *Tool, args []string) error {
+// The tool is stopped when ctx is done.
+func (p *Prm) Exec(ctx context.Context, tool *Tool, args []string) error {
 	if status := p.Backend.Status(); !status.IsAvailable {
-		return ErrDockerNotRunning
+		return p.errBackendNotRunning()
 	}
 
 	// is the tool available?
@@ -27,7 +31,10 @@ func (p *Prm) Exec(tool *Tool, args []string) error {
 	}
 
 	// the tool is available so execute against it
-	exit, err := p.Backend.Exec(tool, args, p.RunningConfig, DirectoryPaths{codeDir: p.CodeDir, cacheDir: p.CacheDir})
+	exit, err := p.Backend.Exec(ctx, tool, args, p.RunningConfig, DirectoryPaths{codeDir: p.CodeDir, cacheDir: p.CacheDir})
+	if ctx.Err() != nil {
+		return fmt.Errorf("execution of tool %s/%s was cancelled", tool.Cfg.Plugin.Author, tool.Cfg.Plugin.Id)
+	}
 	if err != nil {
 		log.Error().Msgf("Error executing tool %s/%s: %s", tool.Cfg.Plugin.Author, tool.Cfg.Plugin.Id, err.Error())
 		return err
@@ -1,6 +1,7 @@
 package prm_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/Masterminds/semver"
@@ -143,7 +144,7 @@ func TestPrm_Exec(t *testing.T) {
 			_ = mapstructure.Decode(toolinfo, &tool.Cfg.Plugin)
 			tt.tool = &tool
 
-			err := tt.p.Exec(tt.tool, tt.args)
+			err := tt.p.Exec(context.Background(), tt.tool, tt.args)
 			// If an error is expected and returned
 			if tt.expectedErrMsg != "" && err != nil {
 				assert.Contains(t, tt.expectedErrMsg, err.Error())
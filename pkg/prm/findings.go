@@ -0,0 +1,251 @@
+package prm
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/shlex"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	OutputModeJson  = "json"
+	OutputModeYaml  = "yaml"
+	OutputModeJunit = "junit"
+
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Finding is a single issue reported by a tool, normalised from
+// whichever structured output format the tool declares
+type Finding struct {
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Severity string `json:"severity"`
+	Rule     string `json:"rule,omitempty"`
+	Message  string `json:"message"`
+}
+
+// Keys that tools commonly use for each part of a finding
+var (
+	findingMessageKeys  = []string{"message", "msg", "description"}
+	findingFileKeys     = []string{"path", "file", "filename", "file_path"}
+	findingLineKeys     = []string{"line", "line_number", "start_line", "lineno"}
+	findingSeverityKeys = []string{"severity", "kind", "level", "type"}
+	findingRuleKeys     = []string{"rule", "check", "cop_name", "rule_id", "code"}
+)
+
+// Returns the structured output format declared by a tool, in order of
+// preference, and the arguments that make the tool produce it
+func (o *OutputModes) preferred() (string, []string, error) {
+	if o == nil {
+		return "", nil, nil
+	}
+
+	for _, mode := range []struct {
+		name string
+		arg  string
+	}{{OutputModeJson, o.Json}, {OutputModeYaml, o.Yaml}, {OutputModeJunit, o.Junit}} {
+		if mode.arg != "" {
+			args, err := shlex.Split(mode.arg)
+			return mode.name, args, err
+		}
+	}
+	return "", nil, nil
+}
+
+// Adds the tool's output mode arguments, keeping the default
+// args which would otherwise be overridden
+func withOutputModeArgs(tool *Tool, args []string) (string, []string, error) {
+	mode, modeArgs, err := tool.Cfg.Common.OutputMode.preferred()
+	if err != nil || mode == "" {
+		return "", args, err
+	}
+
+	if len(args) == 0 {
+		args = tool.Cfg.Common.DefaultArgs
+	}
+	return mode, append(append([]string{}, args...), modeArgs...), nil
+}
+
+func parseFindings(mode string, output string) ([]Finding, error) {
+	findings := []Finding{}
+	if strings.TrimSpace(output) == "" {
+		return findings, nil
+	}
+
+	switch mode {
+	case OutputModeJson:
+		var doc interface{}
+		if err := json.Unmarshal([]byte(output), &doc); err != nil {
+			return nil, fmt.Errorf("unable to parse json output: %s", err)
+		}
+		return walkFindings(doc, "", findings), nil
+	case OutputModeYaml:
+		var doc interface{}
+		if err := yaml.Unmarshal([]byte(output), &doc); err != nil {
+			return nil, fmt.Errorf("unable to parse yaml output: %s", err)
+		}
+		return walkFindings(doc, "", findings), nil
+	case OutputModeJunit:
+		return parseJunitFindings(output)
+	}
+	return nil, fmt.Errorf("unknown output mode '%s'", mode)
+}
+
+// Walks a decoded json/yaml document, treating every object with a message as
+// a finding. Files are inherited from enclosing objects, which covers tools that
+// group findings by file (rubocop) as well as those that list them flat (puppet-lint).
+// The keys of an object are walked in sorted order, so the findings keep the same order.
+func walkFindings(node interface{}, file string, findings []Finding) []Finding {
+	switch value := node.(type) {
+	case []interface{}:
+		for _, item := range value {
+			findings = walkFindings(item, file, findings)
+		}
+	case map[string]interface{}:
+		if f := stringValue(value, findingFileKeys); f != "" {
+			file = f
+		}
+		if message := stringValue(value, findingMessageKeys); message != "" {
+			findings = append(findings, Finding{
+				File:     file,
+				Line:     lineValue(value),
+				Severity: normaliseSeverity(stringValue(value, findingSeverityKeys)),
+				Rule:     stringValue(value, findingRuleKeys),
+				Message:  message,
+			})
+			return findings
+		}
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			findings = walkFindings(value[key], file, findings)
+		}
+	}
+	return findings
+}
+
+func stringValue(node map[string]interface{}, keys []string) string {
+	for _, key := range keys {
+		if value, ok := node[key]; ok {
+			if s, ok := value.(string); ok && s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+func lineValue(node map[string]interface{}) int {
+	for _, key := range findingLineKeys {
+		if line := toInt(node[key]); line > 0 {
+			return line
+		}
+	}
+	// rubocop nests the line within a location object
+	if location, ok := node["location"].(map[string]interface{}); ok {
+		return lineValue(location)
+	}
+	return 0
+}
+
+func toInt(value interface{}) int {
+	switch v := value.(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	case string:
+		i, _ := strconv.Atoi(v)
+		return i
+	}
+	return 0
+}
+
+func normaliseSeverity(severity string) string {
+	switch strings.ToLower(severity) {
+	case "error", "fatal", "failure", "failed":
+		return SeverityError
+	case "info", "note", "notice", "":
+		return SeverityInfo
+	default:
+		return SeverityWarning
+	}
+}
+
+type junitFindingSuites struct {
+	Suites []junitFindingSuite `xml:"testsuite"`
+	// Some tools emit a single <testsuite> as the document root
+	Cases []junitFindingCase `xml:"testcase"`
+}
+
+type junitFindingSuite struct {
+	Suites []junitFindingSuite `xml:"testsuite"`
+	Cases  []junitFindingCase  `xml:"testcase"`
+}
+
+type junitFindingCase struct {
+	Name      string         `xml:"name,attr"`
+	ClassName string         `xml:"classname,attr"`
+	File      string         `xml:"file,attr"`
+	Line      string         `xml:"line,attr"`
+	Failures  []junitMessage `xml:"failure"`
+	Errors    []junitMessage `xml:"error"`
+}
+
+func parseJunitFindings(output string) ([]Finding, error) {
+	var doc junitFindingSuites
+	if err := xml.Unmarshal([]byte(output), &doc); err != nil {
+		return nil, fmt.Errorf("unable to parse junit output: %s", err)
+	}
+
+	findings := junitCaseFindings(doc.Cases, []Finding{})
+	return junitSuiteFindings(doc.Suites, findings), nil
+}
+
+func junitSuiteFindings(suites []junitFindingSuite, findings []Finding) []Finding {
+	for _, suite := range suites {
+		findings = junitCaseFindings(suite.Cases, findings)
+		findings = junitSuiteFindings(suite.Suites, findings)
+	}
+	return findings
+}
+
+func junitCaseFindings(cases []junitFindingCase, findings []Finding) []Finding {
+	for _, testCase := range cases {
+		file := testCase.File
+		if file == "" {
+			file = testCase.ClassName
+		}
+		line, _ := strconv.Atoi(testCase.Line)
+		for _, failure := range append(testCase.Failures, testCase.Errors...) {
+			message := failure.Message
+			if message == "" {
+				message = strings.TrimSpace(failure.Text)
+			}
+			findings = append(findings, Finding{File: file, Line: line, Severity: SeverityError, Rule: testCase.Name, Message: message})
+		}
+	}
+	return findings
+}
+
+func formatFinding(finding Finding) string {
+	location := finding.File
+	if finding.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, finding.Line)
+	}
+	if finding.Rule != "" {
+		return fmt.Sprintf("%s: %s [%s] %s", location, finding.Severity, finding.Rule, finding.Message)
+	}
+	return fmt.Sprintf("%s: %s %s", location, finding.Severity, finding.Message)
+}
@@ -0,0 +1,121 @@
+package prm_test
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/puppetlabs/prm/internal/pkg/mock"
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrm_Validate_Findings(t *testing.T) {
+	outputDir := "path/to/code/.prm-validate"
+	tests := []struct {
+		name         string
+		outputMode   prm.OutputModes
+		defaultArgs  []string
+		toolArgs     []string
+		stdout       string
+		wantArgs     []string
+		wantFindings []prm.Finding
+	}{
+		{
+			name:        "rubocop json output grouped by file",
+			outputMode:  prm.OutputModes{Json: "--format json"},
+			defaultArgs: []string{"--parallel"},
+			stdout: `{"files":[{"path":"spec/spec_helper.rb","offenses":[
+				{"severity":"convention","message":"Use single quotes.","cop_name":"Style/StringLiterals","location":{"line":3,"column":7}},
+				{"severity":"error","message":"Syntax error.","cop_name":"Lint/Syntax","location":{"line":9}}]},
+				{"path":"Rakefile","offenses":[]}]}`,
+			wantArgs: []string{"--parallel", "--format", "json"},
+			wantFindings: []prm.Finding{
+				{File: "spec/spec_helper.rb", Line: 3, Severity: prm.SeverityWarning, Rule: "Style/StringLiterals", Message: "Use single quotes."},
+				{File: "spec/spec_helper.rb", Line: 9, Severity: prm.SeverityError, Rule: "Lint/Syntax", Message: "Syntax error."},
+			},
+		},
+		{
+			name:       "puppet-lint json output listed flat",
+			outputMode: prm.OutputModes{Json: "--json"},
+			toolArgs:   []string{"manifests"},
+			stdout:     `[[{"message":"double quoted string containing no variables","line":2,"column":8,"kind":"warning","check":"double_quoted_strings","path":"manifests/init.pp"}]]`,
+			wantArgs:   []string{"manifests", "--json"},
+			wantFindings: []prm.Finding{
+				{File: "manifests/init.pp", Line: 2, Severity: prm.SeverityWarning, Rule: "double_quoted_strings", Message: "double quoted string containing no variables"},
+			},
+		},
+		{
+			name:       "json output grouped by key, in the order of the keys",
+			outputMode: prm.OutputModes{Json: "--json"},
+			stdout: `{"warnings":[{"file":"manifests/init.pp","line":2,"severity":"warning","message":"unquoted resource title"}],
+				"notices":[{"file":"manifests/init.pp","line":1,"severity":"notice","message":"class not documented"}],
+				"errors":[{"file":"manifests/init.pp","line":5,"severity":"error","message":"bad syntax"}]}`,
+			wantArgs: []string{"--json"},
+			wantFindings: []prm.Finding{
+				{File: "manifests/init.pp", Line: 5, Severity: prm.SeverityError, Message: "bad syntax"},
+				{File: "manifests/init.pp", Line: 1, Severity: prm.SeverityInfo, Message: "class not documented"},
+				{File: "manifests/init.pp", Line: 2, Severity: prm.SeverityWarning, Message: "unquoted resource title"},
+			},
+		},
+		{
+			name:       "yaml output",
+			outputMode: prm.OutputModes{Yaml: "-f yaml"},
+			stdout:     "- file: manifests/init.pp\n  line: 4\n  severity: error\n  rule: syntax\n  message: bad syntax\n",
+			wantArgs:   []string{"-f", "yaml"},
+			wantFindings: []prm.Finding{
+				{File: "manifests/init.pp", Line: 4, Severity: prm.SeverityError, Rule: "syntax", Message: "bad syntax"},
+			},
+		},
+		{
+			name:       "junit output",
+			outputMode: prm.OutputModes{Junit: "--junit"},
+			stdout: `<?xml version="1.0"?><testsuites><testsuite name="syntax">
+				<testcase name="manifests" classname="manifests/init.pp"><failure message="Syntax error at 'Kernel'"/></testcase>
+				<testcase name="templates" classname="templates/motd.epp"/></testsuite></testsuites>`,
+			wantArgs: []string{"--junit"},
+			wantFindings: []prm.Finding{
+				{File: "manifests/init.pp", Severity: prm.SeverityError, Rule: "manifests", Message: "Syntax error at 'Kernel'"},
+			},
+		},
+		{
+			name:         "json output with no findings",
+			outputMode:   prm.OutputModes{Json: "--json"},
+			stdout:       `[]`,
+			wantArgs:     []string{"--json"},
+			wantFindings: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			afs := &afero.Afero{Fs: fs}
+			backend := &mock.MockBackend{
+				StatusIsAvailable: true,
+				ToolAvalible:      true,
+				ValidateReturn:    "FAIL",
+				ValidateStdout:    tt.stdout,
+			}
+			p := &prm.Prm{AFS: afs, IOFS: &afero.IOFS{Fs: fs}, CodeDir: "path/to/code", Backend: backend}
+
+			toolInfo := CreateToolInfo("my-tool", "puppetlabs", "0.1.0", tt.toolArgs)
+			toolInfo.Tool.Cfg.Common.OutputMode = &tt.outputMode
+			toolInfo.Tool.Cfg.Common.DefaultArgs = tt.defaultArgs
+
+			_ = p.Validate(context.Background(), []prm.ToolInfo{toolInfo}, 1, prm.OutputSettings{ResultsView: "terminal", OutputDir: outputDir, ReportFormat: prm.ReportFormatJson})
+			assert.Equal(t, tt.wantArgs, backend.ValidateArgs)
+
+			content, err := afs.ReadFile(filepath.Join(outputDir, "report.json"))
+			assert.NoError(t, err)
+			var report struct {
+				Tools []struct {
+					Findings []prm.Finding `json:"findings"`
+				} `json:"tools"`
+			}
+			assert.NoError(t, json.Unmarshal(content, &report))
+			assert.Equal(t, tt.wantFindings, report.Tools[0].Findings)
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package prm
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/go-units"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/olekukonko/tablewriter"
+)
+
+func findGroup(groups []Group, id string) (Group, bool) {
+	for _, group := range groups {
+		if group.ID == id {
+			return group, true
+		}
+	}
+	return Group{}, false
+}
+
+// Works out the tools a group runs: the tools of the group it extends, then
+// those of the groups it includes, then its own tools and those of its stages.
+// The stages of each run after those before it, so the stages of the group
+// it extends run first and its own stages last. A tool of its own that it also inherits overrides the args, env, timeout,
+// cpus, memory and needs of the inherited tool. Each tool is given a unique ID.
+func resolveGroup(groups []Group, group Group) (Group, error) {
+	group, err := resolveGroupChain(groups, group, nil)
+	if err != nil {
+		return Group{}, err
+	}
+	group.Tools, err = assignInvocationIDs(group)
+	if err != nil {
+		return Group{}, err
+	}
+	if err := checkToolDependencies(group); err != nil {
+		return Group{}, err
+	}
+	if err := checkToolLimits(group); err != nil {
+		return Group{}, err
+	}
+	return group, nil
+}
+
+// Checks the timeout, cpus and memory set for the tools of a group are valid
+func checkToolLimits(group Group) error {
+	for _, tool := range group.Tools {
+		if tool.Timeout < 0 {
+			return fmt.Errorf("invalid timeout %d for '%s' in the tool group '%s', must be a positive number of seconds", tool.Timeout, tool.ID, group.ID)
+		}
+		if tool.Cpus < 0 {
+			return fmt.Errorf("invalid cpus %v for '%s' in the tool group '%s', must be positive", tool.Cpus, tool.ID, group.ID)
+		}
+		if tool.Memory != "" {
+			if _, err := units.RAMInBytes(tool.Memory); err != nil {
+				return fmt.Errorf("invalid memory '%s' for '%s' in the tool group '%s': %s", tool.Memory, tool.ID, group.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// chain holds the IDs of the groups that led to this one, to detect cycles
+func resolveGroupChain(groups []Group, group Group, chain []string) (Group, error) {
+	// The built-in groups are defined by PRM
+	if group.Builtin {
+		return group, nil
+	}
+
+	for i, id := range chain {
+		if id == group.ID {
+			return Group{}, fmt.Errorf("tool group cycle found: %s", strings.Join(append(chain[i:], group.ID), " -> "))
+		}
+	}
+	chain = append(chain[:len(chain):len(chain)], group.ID)
+
+	stageTools := group.stageTools()
+	for _, tool := range stageTools {
+		if strings.HasPrefix(tool.Name, BuiltinGroupPrefix) {
+			return Group{}, fmt.Errorf("built-in tool group '%s' cannot run in a stage of '%s', include it instead", tool.Name, group.ID)
+		}
+	}
+	err := checkDuplicateToolsInGroups(append(append([]ToolInst{}, group.Tools...), stageTools...))
+	if err != nil {
+		return Group{}, err
+	}
+	own, err := expandBuiltinGroups(group.Tools)
+	if err != nil {
+		return Group{}, err
+	}
+
+	parents := group.Include
+	if group.Extends != "" {
+		parents = append([]string{group.Extends}, group.Include...)
+	}
+
+	var tools []ToolInst
+	lastStage := 0
+	for _, parentID := range parents {
+		parent, ok := findGroup(groups, parentID)
+		if !ok {
+			return Group{}, fmt.Errorf("tool group '%s' used by '%s' not found", parentID, group.ID)
+		}
+		parent, err = resolveGroupChain(groups, parent, chain)
+		if err != nil {
+			return Group{}, err
+		}
+		if parentID == group.Extends && len(group.PuppetVersions) == 0 {
+			group.PuppetVersions = parent.PuppetVersions
+		}
+		var parentTools []ToolInst
+		parentTools, lastStage = shiftStages(parent.Tools, lastStage)
+		tools = append(tools, parentTools...)
+	}
+	err = checkDuplicateToolsInGroups(tools)
+	if err != nil {
+		return Group{}, fmt.Errorf("tool group '%s': %s", group.ID, err)
+	}
+
+	own, lastStage = shiftStages(own, lastStage)
+	stageTools, _ = shiftStages(stageTools, lastStage)
+	own = append(own, stageTools...)
+
+	// A tool with an ID overrides the inherited run of the tool with
+	// that ID, and a tool without one every inherited run of the tool
+	inheritedCount := len(tools)
+	for _, tool := range own {
+		var matches []int
+		for i := 0; i < inheritedCount; i++ {
+			if (tool.ID == "" && tools[i].Name == tool.Name) || (tool.ID != "" && tools[i].Name == tool.Name && tools[i].invocationID() == tool.ID) {
+				matches = append(matches, i)
+			}
+		}
+		if len(matches) == 0 {
+			tools = append(tools, tool)
+			continue
+		}
+		if len(matches) > 1 && tool.Args != nil {
+			return Group{}, fmt.Errorf("tool '%s' is run more than once by the groups '%s' inherits, so its args cannot be overridden. Set the id of the run to override", tool.Name, group.ID)
+		}
+		for _, i := range matches {
+			tools[i] = overrideToolInst(tools[i], tool)
+		}
+	}
+
+	group.Tools = tools
+	group.Stages = nil
+	return group, nil
+}
+
+// Applies the args, env, timeout, cpus, memory and needs set by override to tool
+func overrideToolInst(tool ToolInst, override ToolInst) ToolInst {
+	if override.Args != nil {
+		tool.Args = override.Args
+	}
+	if len(override.Env) > 0 {
+		env := make(map[string]string)
+		for key, val := range tool.Env {
+			env[key] = val
+		}
+		for key, val := range override.Env {
+			env[key] = val
+		}
+		tool.Env = env
+	}
+	if override.Timeout > 0 {
+		tool.Timeout = override.Timeout
+	}
+	if override.Cpus > 0 {
+		tool.Cpus = override.Cpus
+	}
+	if override.Memory != "" {
+		tool.Memory = override.Memory
+	}
+	if override.Needs != nil {
+		tool.Needs = override.Needs
+	}
+	return tool
+}
+
+// FormatGroupExplanation formats the tools a resolved group runs, with the args, env,
+// timeout and resource limits each is run with and what it waits for, in table format or json format
+func FormatGroupExplanation(group Group, outputFormat string) (string, error) {
+	switch outputFormat {
+	case "table":
+		stringBuilder := &strings.Builder{}
+		stringBuilder.WriteString(fmt.Sprintf("Tool group: %s\n", group.ID))
+		if group.Extends != "" {
+			stringBuilder.WriteString(fmt.Sprintf("Extends:    %s\n", group.Extends))
+		}
+		if len(group.Include) > 0 {
+			stringBuilder.WriteString(fmt.Sprintf("Includes:   %s\n", strings.Join(group.Include, ", ")))
+		}
+		if len(group.PuppetVersions) > 0 {
+			stringBuilder.WriteString(fmt.Sprintf("Puppet:     %s\n", strings.Join(group.PuppetVersions, ", ")))
+		}
+		stringBuilder.WriteString("\n")
+
+		table := tablewriter.NewWriter(stringBuilder)
+		table.SetHeader([]string{"ID", "Tool", "Args", "Env", "Timeout", "CPUs", "Memory", "Stage", "Needs"})
+		table.SetBorder(false)
+		table.SetAutoWrapText(false)
+		for _, tool := range group.Tools {
+			var env []string
+			for key, val := range tool.Env {
+				env = append(env, key+"="+val)
+			}
+			sort.Strings(env)
+			timeout := "default"
+			if tool.Timeout > 0 {
+				timeout = strconv.Itoa(tool.Timeout) + "s"
+			}
+			cpus := ""
+			if tool.Cpus > 0 {
+				cpus = strconv.FormatFloat(tool.Cpus, 'f', -1, 64)
+			}
+			stage := "-"
+			if tool.Stage > 0 {
+				stage = strconv.Itoa(tool.Stage)
+			}
+			table.Append([]string{tool.ID, tool.Name, strings.Join(tool.Args, " "), strings.Join(env, " "), timeout, cpus, tool.Memory, stage, strings.Join(tool.Needs, ", ")})
+		}
+		table.Render()
+		return stringBuilder.String(), nil
+	case "json":
+		prettyJSON, _ := jsoniter.ConfigFastest.MarshalIndent(&group, "", "  ")
+		return string(prettyJSON), nil
+	}
+	return "", fmt.Errorf("unknown format '%s', must be one of [table|json]", outputFormat)
+}
@@ -0,0 +1,476 @@
+package prm_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrm_GetValidationGroupFromFile_Composition(t *testing.T) {
+	tests := []struct {
+		name           string
+		validateYml    string
+		selectedGroup  string
+		expectedTools  []prm.ToolInst
+		expectedPuppet []string
+		expectedErr    string
+	}{
+		{
+			name: "Should run the tools of the extended group first",
+			validateYml: `groups:
+  - id: base
+    puppet_versions: ["7.15.0"]
+    tools:
+      - name: puppetlabs/lint
+  - id: ci
+    extends: base
+    tools:
+      - name: puppetlabs/epp
+`,
+			selectedGroup:  "ci",
+			expectedTools:  []prm.ToolInst{{Name: "puppetlabs/lint", ID: "lint"}, {Name: "puppetlabs/epp", ID: "epp"}},
+			expectedPuppet: []string{"7.15.0"},
+		},
+		{
+			name: "Should run the tools of included groups",
+			validateYml: `groups:
+  - id: lint
+    tools:
+      - name: puppetlabs/lint
+  - id: syntax
+    tools:
+      - name: puppetlabs/syntax
+  - id: ci
+    include: [lint, syntax]
+`,
+			selectedGroup: "ci",
+			expectedTools: []prm.ToolInst{{Name: "puppetlabs/lint", ID: "lint"}, {Name: "puppetlabs/syntax", ID: "syntax"}},
+		},
+		{
+			name: "Should resolve nested includes",
+			validateYml: `groups:
+  - id: lint
+    tools:
+      - name: puppetlabs/lint
+  - id: quick
+    include: [lint]
+    tools:
+      - name: puppetlabs/syntax
+  - id: ci
+    extends: quick
+    tools:
+      - name: puppetlabs/epp
+`,
+			selectedGroup: "ci",
+			expectedTools: []prm.ToolInst{{Name: "puppetlabs/lint", ID: "lint"}, {Name: "puppetlabs/syntax", ID: "syntax"}, {Name: "puppetlabs/epp", ID: "epp"}},
+		},
+		{
+			name: "Should override the args, env and timeout of an inherited tool",
+			validateYml: `groups:
+  - id: base
+    tools:
+      - name: puppetlabs/lint
+        args: [--fail-on-warnings]
+        env:
+          LANG: C
+      - name: puppetlabs/epp
+        args: [--strict]
+  - id: ci
+    extends: base
+    tools:
+      - name: puppetlabs/lint
+        env:
+          DEBUG: "1"
+        timeout: 60
+      - name: puppetlabs/epp
+        args: []
+`,
+			selectedGroup: "ci",
+			expectedTools: []prm.ToolInst{
+				{Name: "puppetlabs/lint", ID: "lint", Args: []string{"--fail-on-warnings"}, Env: map[string]string{"LANG": "C", "DEBUG": "1"}, Timeout: 60},
+				{Name: "puppetlabs/epp", ID: "epp", Args: []string{}},
+			},
+		},
+		{
+			name: "Should extend a built-in group",
+			validateYml: `groups:
+  - id: ci
+    extends: group/modules
+    tools:
+      - name: puppetlabs/rubocop
+        timeout: 60
+`,
+			selectedGroup: "ci",
+			expectedTools: func() []prm.ToolInst {
+				tools := append([]prm.ToolInst{}, modulesTools()...)
+				for i := range tools {
+					if tools[i].Name == "puppetlabs/rubocop" {
+						tools[i].Timeout = 60
+					}
+				}
+				return tools
+			}(),
+		},
+		{
+			name: "Should error overriding the args of a tool inherited more than once",
+			validateYml: `groups:
+  - id: ci
+    include: [group/modules]
+    tools:
+      - name: puppetlabs/spec_puppet
+        args: [--verbose]
+`,
+			selectedGroup: "ci",
+			expectedErr:   "its args cannot be overridden",
+		},
+		{
+			name: "Should error for an include cycle",
+			validateYml: `groups:
+  - id: a
+    include: [b]
+  - id: b
+    extends: c
+  - id: c
+    include: [a]
+`,
+			selectedGroup: "a",
+			expectedErr:   "tool group cycle found: a -> b -> c -> a",
+		},
+		{
+			name: "Should error for a group that extends itself",
+			validateYml: `groups:
+  - id: a
+    extends: a
+`,
+			selectedGroup: "a",
+			expectedErr:   "tool group cycle found: a -> a",
+		},
+		{
+			name: "Should error for an unknown included group",
+			validateYml: `groups:
+  - id: ci
+    include: [lint]
+`,
+			selectedGroup: "ci",
+			expectedErr:   "tool group 'lint' used by 'ci' not found",
+		},
+		{
+			name: "Should error for a tool inherited from two groups",
+			validateYml: `groups:
+  - id: lint
+    tools:
+      - name: puppetlabs/lint
+  - id: quick
+    tools:
+      - name: puppetlabs/lint
+  - id: ci
+    include: [lint, quick]
+`,
+			selectedGroup: "ci",
+			expectedErr:   "duplicate tool 'puppetlabs/lint' found",
+		},
+		{
+			name: "Should error for a duplicate tool in the group itself",
+			validateYml: `groups:
+  - id: base
+    tools:
+      - name: puppetlabs/lint
+  - id: ci
+    extends: base
+    tools:
+      - name: puppetlabs/epp
+      - name: puppetlabs/epp
+`,
+			selectedGroup: "ci",
+			expectedErr:   "duplicate tool 'puppetlabs/epp' found",
+		},
+		{
+			name: "Should run a tool more than once with different args",
+			validateYml: `groups:
+  - id: ci
+    tools:
+      - name: puppetlabs/spec
+        args: [spec_prep]
+      - name: puppetlabs/spec
+      - name: puppetlabs/spec
+        id: spec_clean
+        args: [spec_clean]
+`,
+			selectedGroup: "ci",
+			expectedTools: []prm.ToolInst{
+				{Name: "puppetlabs/spec", ID: "spec", Args: []string{"spec_prep"}},
+				{Name: "puppetlabs/spec", ID: "spec_2"},
+				{Name: "puppetlabs/spec", ID: "spec_clean", Args: []string{"spec_clean"}},
+			},
+		},
+		{
+			name: "Should error for two runs of a tool with the same id",
+			validateYml: `groups:
+  - id: ci
+    tools:
+      - name: puppetlabs/spec
+        id: unit
+      - name: puppetlabs/lint
+        id: unit
+`,
+			selectedGroup: "ci",
+			expectedErr:   "duplicate id 'unit' found in the tool group 'ci'",
+		},
+		{
+			name: "Should override the inherited run of a tool with its id",
+			validateYml: `groups:
+  - id: base
+    tools:
+      - name: puppetlabs/spec
+        id: prep
+        args: [spec_prep]
+      - name: puppetlabs/spec
+  - id: ci
+    extends: base
+    tools:
+      - name: puppetlabs/spec
+        id: prep
+        args: [spec_prep, --verbose]
+`,
+			selectedGroup: "ci",
+			expectedTools: []prm.ToolInst{
+				{Name: "puppetlabs/spec", ID: "prep", Args: []string{"spec_prep", "--verbose"}},
+				{Name: "puppetlabs/spec", ID: "spec"},
+			},
+		},
+		{
+			name: "Should error for overriding the args of a tool run more than once without an id",
+			validateYml: `groups:
+  - id: base
+    tools:
+      - name: puppetlabs/spec
+        args: [spec_prep]
+      - name: puppetlabs/spec
+  - id: ci
+    extends: base
+    tools:
+      - name: puppetlabs/spec
+        args: [--verbose]
+`,
+			selectedGroup: "ci",
+			expectedErr:   "Set the id of the run to override",
+		},
+		{
+			name: "Should need a run of a tool by its id",
+			validateYml: `groups:
+  - id: ci
+    tools:
+      - name: puppetlabs/spec
+        id: prep
+        args: [spec_prep]
+      - name: puppetlabs/spec
+        needs: [prep]
+`,
+			selectedGroup: "ci",
+			expectedTools: []prm.ToolInst{
+				{Name: "puppetlabs/spec", ID: "prep", Args: []string{"spec_prep"}},
+				{Name: "puppetlabs/spec", ID: "spec", Needs: []string{"prep"}},
+			},
+		},
+		{
+			name: "Should override the resource limits of an inherited tool",
+			validateYml: `groups:
+  - id: base
+    tools:
+      - name: puppetlabs/spec
+        cpus: 2
+        memory: 1g
+      - name: puppetlabs/lint
+        cpus: 0.5
+  - id: ci
+    extends: base
+    tools:
+      - name: puppetlabs/spec
+        memory: 2g
+`,
+			selectedGroup: "ci",
+			expectedTools: []prm.ToolInst{
+				{Name: "puppetlabs/spec", ID: "spec", Cpus: 2, Memory: "2g"},
+				{Name: "puppetlabs/lint", ID: "lint", Cpus: 0.5},
+			},
+		},
+		{
+			name: "Should error for an invalid memory limit",
+			validateYml: `groups:
+  - id: ci
+    tools:
+      - name: puppetlabs/spec
+        memory: plenty
+`,
+			selectedGroup: "ci",
+			expectedErr:   "invalid memory 'plenty' for 'spec' in the tool group 'ci'",
+		},
+		{
+			name: "Should error for a negative cpus limit",
+			validateYml: `groups:
+  - id: ci
+    tools:
+      - name: puppetlabs/spec
+        cpus: -1
+`,
+			selectedGroup: "ci",
+			expectedErr:   "invalid cpus -1 for 'spec' in the tool group 'ci'",
+		},
+		{
+			name: "Should number the stages of a group after its tools",
+			validateYml: `groups:
+  - id: ci
+    tools:
+      - name: puppetlabs/lint
+    stages:
+      - name: prepare
+        tools:
+          - name: puppetlabs/cache
+      - name: test
+        tools:
+          - name: puppetlabs/spec
+            needs: [puppetlabs/lint]
+`,
+			selectedGroup: "ci",
+			expectedTools: []prm.ToolInst{
+				{Name: "puppetlabs/lint", ID: "lint"},
+				{Name: "puppetlabs/cache", ID: "cache", Stage: 1},
+				{Name: "puppetlabs/spec", ID: "spec", Stage: 2, Needs: []string{"puppetlabs/lint"}},
+			},
+		},
+		{
+			name: "Should run the stages of a group after those of the group it extends",
+			validateYml: `groups:
+  - id: base
+    stages:
+      - tools:
+          - name: puppetlabs/cache
+      - tools:
+          - name: puppetlabs/spec
+  - id: ci
+    extends: base
+    stages:
+      - tools:
+          - name: puppetlabs/lint
+      - tools:
+          - name: puppetlabs/docs
+`,
+			selectedGroup: "ci",
+			expectedTools: []prm.ToolInst{
+				{Name: "puppetlabs/cache", ID: "cache", Stage: 1},
+				{Name: "puppetlabs/spec", ID: "spec", Stage: 2},
+				{Name: "puppetlabs/lint", ID: "lint", Stage: 3},
+				{Name: "puppetlabs/docs", ID: "docs", Stage: 4},
+			},
+		},
+		{
+			name: "Should run the stages of a group after those of the built-in groups it runs",
+			validateYml: `groups:
+  - id: ci
+    tools:
+      - name: group/modules
+    stages:
+      - tools:
+          - name: puppetlabs/docs
+`,
+			selectedGroup: "ci",
+			expectedTools: append(modulesTools(), prm.ToolInst{Name: "puppetlabs/docs", ID: "docs", Stage: 4}),
+		},
+		{
+			name: "Should error for a tool that needs a tool outside of the group",
+			validateYml: `groups:
+  - id: ci
+    tools:
+      - name: puppetlabs/spec
+        needs: [puppetlabs/cache]
+`,
+			selectedGroup: "ci",
+			expectedErr:   "tool 'puppetlabs/spec' needs 'puppetlabs/cache', which is not in the tool group 'ci'",
+		},
+		{
+			name: "Should error for tools that need each other",
+			validateYml: `groups:
+  - id: ci
+    stages:
+      - tools:
+          - name: puppetlabs/cache
+            needs: [puppetlabs/spec]
+      - tools:
+          - name: puppetlabs/spec
+`,
+			selectedGroup: "ci",
+			expectedErr:   "form a cycle: puppetlabs/cache -> puppetlabs/spec -> puppetlabs/cache",
+		},
+		{
+			name: "Should error for a tool in a stage and the tools of the group",
+			validateYml: `groups:
+  - id: ci
+    tools:
+      - name: puppetlabs/lint
+    stages:
+      - tools:
+          - name: puppetlabs/lint
+`,
+			selectedGroup: "ci",
+			expectedErr:   "duplicate tool 'puppetlabs/lint' found",
+		},
+		{
+			name: "Should error for a built-in group in a stage",
+			validateYml: `groups:
+  - id: ci
+    stages:
+      - tools:
+          - name: group/modules
+`,
+			selectedGroup: "ci",
+			expectedErr:   "built-in tool group 'group/modules' cannot run in a stage of 'ci'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := stubInstalledTools(t)
+			p.CodeDir = "path/to/code"
+			p.AFS.MkdirAll(p.CodeDir, 0750)                                                         //nolint:errcheck
+			p.AFS.WriteFile(filepath.Join(p.CodeDir, "validate.yml"), []byte(tt.validateYml), 0644) //nolint:errcheck
+
+			group, err := p.GetValidationGroupFromFile(tt.selectedGroup)
+			if tt.expectedErr != "" {
+				assert.ErrorContains(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedTools, group.Tools)
+			assert.Equal(t, tt.expectedPuppet, group.PuppetVersions)
+		})
+	}
+}
+
+func TestFormatGroupExplanation(t *testing.T) {
+	group := prm.Group{
+		ID:      "ci",
+		Extends: "base",
+		Include: []string{"lint"},
+		Tools: []prm.ToolInst{
+			{Name: "puppetlabs/lint", ID: "lint", Args: []string{"--fail-on-warnings"}, Env: map[string]string{"LANG": "C"}, Timeout: 60, Cpus: 1.5, Memory: "512m"},
+			{Name: "puppetlabs/epp", ID: "epp"},
+		},
+	}
+
+	output, err := prm.FormatGroupExplanation(group, "table")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "Tool group: ci")
+	assert.Contains(t, output, "Extends:    base")
+	assert.Contains(t, output, "Includes:   lint")
+	assert.Regexp(t, `puppetlabs/lint\s+\|\s+--fail-on-warnings\s+\|\s+LANG=C\s+\|\s+60s`, output)
+	assert.Regexp(t, `puppetlabs/epp\s+\|\s+\|\s+\|\s+default`, output)
+	assert.Regexp(t, `puppetlabs/lint\s+\|.*60s\s+\|\s+1\.5\s+\|\s+512m`, output)
+
+	output, err = prm.FormatGroupExplanation(group, "json")
+	assert.NoError(t, err)
+	assert.Contains(t, output, `"timeout": 60`)
+
+	_, err = prm.FormatGroupExplanation(group, "yaml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,145 @@
+package prm
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// The files whose patterns exclude paths in the codedir from PRM's view of it
+var IgnoreFileNames = []string{".gitignore", ".pdkignore"}
+
+// Directories in the codedir that PRM never treats as content
+var ignoredDirNames = []string{".git", ".prm-validate"}
+
+// Files in the root of the codedir that hold PRM's own state rather than content
+var prmStateFileNames = []string{BaselineFileName, LockFileName}
+
+// ignoreRule is a single pattern from a .gitignore style file
+type ignoreRule struct {
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+	// The directory of the ignore file, relative to the codedir, or "" for its root
+	base string
+}
+
+// Reads the ignore rules from the ignore files throughout the codedir, skipping
+// the directories they ignore. Later rules take precedence over earlier ones and
+// those of a nested ignore file over those of the directories above it, as in git.
+func (p *Prm) readIgnoreRules() []ignoreRule {
+	rules := p.readIgnoreFiles("")
+	cacheDir, _ := filepath.Abs(p.CacheDir)
+	_ = p.AFS.Walk(p.CodeDir, func(file string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return err
+		}
+		relPath, err := filepath.Rel(p.CodeDir, file)
+		if err != nil || relPath == "." {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		if absPath, _ := filepath.Abs(file); (p.CacheDir != "" && absPath == cacheDir) || isIgnored(rules, relPath, true) {
+			return filepath.SkipDir
+		}
+		rules = append(rules, p.readIgnoreFiles(relPath)...)
+		return nil
+	})
+	return rules
+}
+
+// Reads the ignore rules from the ignore files in a directory of the codedir
+func (p *Prm) readIgnoreFiles(relDir string) []ignoreRule {
+	var rules []ignoreRule
+	for _, name := range IgnoreFileNames {
+		content, err := p.AFS.ReadFile(filepath.Join(p.CodeDir, filepath.FromSlash(relDir), name))
+		if err != nil {
+			continue
+		}
+		for _, rule := range parseIgnoreRules(content) {
+			rule.base = relDir
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func parseIgnoreRules(content []byte) []ignoreRule {
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		line = strings.TrimPrefix(line, "**/")
+		// A slash anywhere but the end anchors the pattern to the ignore file's directory
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// Whether a path, relative to the codedir and using forward slashes, is ignored.
+// The contents of an ignored directory are expected to be skipped by the caller.
+func isIgnored(rules []ignoreRule, relPath string, isDir bool) bool {
+	if isDir {
+		for _, name := range ignoredDirNames {
+			if relPath == name {
+				return true
+			}
+		}
+	}
+
+	ignored := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		target := relPath
+		if rule.base != "" {
+			if !strings.HasPrefix(relPath, rule.base+"/") {
+				continue
+			}
+			target = strings.TrimPrefix(relPath, rule.base+"/")
+		}
+		if !rule.anchored {
+			target = path.Base(target)
+		}
+		if matched, _ := path.Match(rule.pattern, target); matched {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// Whether a path, relative to the codedir and using forward slashes, is one of PRM's state files
+func isPrmStateFile(relPath string) bool {
+	for _, name := range prmStateFileNames {
+		if relPath == name {
+			return true
+		}
+	}
+	return false
+}
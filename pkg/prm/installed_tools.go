@@ -0,0 +1,157 @@
+package prm
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/hashicorp/go-version"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/olekukonko/tablewriter"
+	"github.com/rs/zerolog/log"
+)
+
+// ToolImageRemoverI is implemented by backends that build images for tools
+type ToolImageRemoverI interface {
+	// Removes the images built for the tool for any Puppet version,
+	// returning the names of the images removed
+	RemoveToolImages(tool *Tool) ([]string, error)
+}
+
+// ListAllVersions returns every version of every tool installed under the
+// tool path, sorted by author, id and version
+func (p *Prm) ListAllVersions(toolPath string) ([]ToolConfig, error) {
+	tools := p.readToolConfigs(toolPath, false)
+	if len(tools) == 0 {
+		return nil, fmt.Errorf("no tools found in %+v", toolPath)
+	}
+	sortToolVersions(tools)
+	return tools, nil
+}
+
+// FormatToolVersions formats the installed tool versions to display on the
+// console in table format or json format.
+func (*Prm) FormatToolVersions(tools []ToolConfig, outputFormat string) (string, error) {
+	switch outputFormat {
+	case "table":
+		stringBuilder := &strings.Builder{}
+		table := tablewriter.NewWriter(stringBuilder)
+		table.SetHeader([]string{"Author", "Name", "Version", "Path"})
+		table.SetBorder(false)
+		for _, tool := range tools {
+			table.Append([]string{tool.Plugin.Author, tool.Plugin.Id, tool.Plugin.Version, tool.Path})
+		}
+		table.Render()
+		return stringBuilder.String(), nil
+	case "json":
+		prettyJSON, _ := jsoniter.ConfigFastest.MarshalIndent(&tools, "", "  ")
+		return string(prettyJSON), nil
+	}
+	return "", fmt.Errorf("unknown format '%s', must be one of [table|json]", outputFormat)
+}
+
+// UninstallTool removes a tool, given as author/id[@version], from the tool path.
+// Without a version every installed version of the tool is removed.
+func (p *Prm) UninstallTool(toolPath string, name string) ([]ToolConfig, error) {
+	toolName, toolVersion, _ := strings.Cut(name, "@")
+	author, id, ok := strings.Cut(toolName, "/")
+	if !ok || author == "" || id == "" {
+		return nil, fmt.Errorf("tool must be in AUTHOR/ID[@VERSION] format")
+	}
+
+	tools := p.FilterFiles(p.readToolConfigs(toolPath, false), func(f ToolConfig) bool {
+		return f.Plugin.Author == author && f.Plugin.Id == id && (toolVersion == "" || f.Plugin.Version == toolVersion)
+	})
+	if len(tools) == 0 {
+		if toolVersion != "" {
+			return nil, fmt.Errorf("version %s of tool %s is not installed in %s", toolVersion, toolName, toolPath)
+		}
+		return nil, fmt.Errorf("tool %s is not installed in %s", toolName, toolPath)
+	}
+	sortToolVersions(tools)
+
+	for _, tool := range tools {
+		log.Debug().Msgf("Removing %s", tool.Path)
+		if err := p.AFS.RemoveAll(tool.Path); err != nil {
+			return nil, fmt.Errorf("unable to remove %s: %s", tool.Path, err)
+		}
+	}
+
+	// Tidy up the author/id and author directories once they are empty
+	toolDir := filepath.Dir(tools[0].Path)
+	for _, dir := range []string{toolDir, filepath.Dir(toolDir)} {
+		if empty, err := p.AFS.IsEmpty(dir); err == nil && empty {
+			if err := p.AFS.Remove(dir); err != nil {
+				log.Warn().Msgf("Unable to remove empty directory %s: %s", dir, err)
+			}
+		}
+	}
+
+	return tools, nil
+}
+
+func sortToolVersions(tools []ToolConfig) {
+	sort.SliceStable(tools, func(i, j int) bool {
+		a, b := tools[i].Plugin, tools[j].Plugin
+		if a.Author != b.Author {
+			return a.Author < b.Author
+		}
+		if a.Id != b.Id {
+			return a.Id < b.Id
+		}
+		aVersion, aErr := version.NewVersion(a.Version)
+		bVersion, bErr := version.NewVersion(b.Version)
+		if aErr != nil || bErr != nil {
+			return a.Version < b.Version
+		}
+		return aVersion.LessThan(bVersion)
+	})
+}
+
+func (p *Prm) createVersionCache(tmpls []ToolConfig) {
+	p.versions = make(map[string][]*Tool)
+	for _, t := range tmpls {
+		name := t.Plugin.Author + "/" + t.Plugin.Id
+		p.versions[name] = append(p.versions[name], &Tool{Cfg: t})
+	}
+}
+
+// Resolves a version constraint, e.g. "1.2.0" or "~1.2", to the newest
+// installed version of the tool that satisfies it
+func (p *Prm) resolveToolVersion(name string, constraint string) (*Tool, error) {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version constraint '%s' for tool %s: %s", constraint, name, err)
+	}
+
+	installed := p.versions[name]
+	if len(installed) == 0 && p.Cache[name] != nil {
+		installed = []*Tool{p.Cache[name]}
+	}
+	if len(installed) == 0 {
+		return nil, fmt.Errorf("Tool %s not found in cache", name)
+	}
+
+	var match *Tool
+	var matchVersion *semver.Version
+	var available []string
+	for _, tool := range installed {
+		available = append(available, tool.Cfg.Plugin.Version)
+		v, err := semver.NewVersion(tool.Cfg.Plugin.Version)
+		if err != nil {
+			log.Debug().Msgf("Ignoring %s with an invalid version: %s", name, tool.Cfg.Plugin.Version)
+			continue
+		}
+		if c.Check(v) && (matchVersion == nil || v.GreaterThan(matchVersion)) {
+			match, matchVersion = tool, v
+		}
+	}
+
+	if match == nil {
+		sort.Strings(available)
+		return nil, fmt.Errorf("no installed version of tool %s satisfies '%s'; installed versions: %s", name, constraint, strings.Join(available, ", "))
+	}
+	return match, nil
+}
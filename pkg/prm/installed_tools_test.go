@@ -0,0 +1,177 @@
+package prm_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/puppetlabs/prm/internal/pkg/mock"
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+const installedToolsPath = "stubbed/tools"
+
+func stubInstalledTools(t *testing.T, tools ...string) *prm.Prm {
+	fs := afero.NewMemMapFs()
+	afs := &afero.Afero{Fs: fs}
+	for _, tool := range tools {
+		var author, id, version string
+		_, err := fmt.Sscanf(tool, "%s %s %s", &author, &id, &version)
+		assert.NoError(t, err)
+		toolDir := filepath.Join(installedToolsPath, author, id, version)
+		afs.MkdirAll(toolDir, 0750) //nolint:errcheck
+		config := fmt.Sprintf("plugin:\n  author: %s\n  id: %s\n  display: %s\n  version: %s\n", author, id, id, version)
+		afs.WriteFile(filepath.Join(toolDir, "prm-config.yml"), []byte(config), 0644) //nolint:errcheck
+	}
+	return &prm.Prm{AFS: afs, IOFS: &afero.IOFS{Fs: fs}}
+}
+
+func toolVersions(tools []prm.ToolConfig) (versions []string) {
+	for _, tool := range tools {
+		versions = append(versions, fmt.Sprintf("%s/%s@%s", tool.Plugin.Author, tool.Plugin.Id, tool.Plugin.Version))
+	}
+	return versions
+}
+
+func TestListAllVersions(t *testing.T) {
+	p := stubInstalledTools(t, "puppetlabs lint 0.10.0", "puppetlabs lint 0.9.0", "puppetlabs epp 0.1.0", "acme lint 1.0.0")
+
+	tools, err := p.ListAllVersions(installedToolsPath)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"acme/lint@1.0.0", "puppetlabs/epp@0.1.0", "puppetlabs/lint@0.9.0", "puppetlabs/lint@0.10.0"}, toolVersions(tools))
+
+	_, err = stubInstalledTools(t).ListAllVersions(installedToolsPath)
+	assert.Error(t, err)
+}
+
+func TestUninstallTool(t *testing.T) {
+	tests := []struct {
+		name          string
+		tool          string
+		wantRemoved   []string
+		wantRemaining []string
+		wantErr       string
+	}{
+		{
+			name:          "Uninstalls a single version",
+			tool:          "puppetlabs/lint@0.9.0",
+			wantRemoved:   []string{"puppetlabs/lint@0.9.0"},
+			wantRemaining: []string{"puppetlabs/epp@0.1.0", "puppetlabs/lint@0.10.0"},
+		},
+		{
+			name:          "Uninstalls every version",
+			tool:          "puppetlabs/lint",
+			wantRemoved:   []string{"puppetlabs/lint@0.9.0", "puppetlabs/lint@0.10.0"},
+			wantRemaining: []string{"puppetlabs/epp@0.1.0"},
+		},
+		{
+			name:    "Errors for a version that is not installed",
+			tool:    "puppetlabs/lint@1.0.0",
+			wantErr: "version 1.0.0 of tool puppetlabs/lint is not installed in stubbed/tools",
+		},
+		{
+			name:    "Errors for a tool that is not installed",
+			tool:    "puppetlabs/rubocop",
+			wantErr: "tool puppetlabs/rubocop is not installed in stubbed/tools",
+		},
+		{
+			name:    "Errors for an invalid name",
+			tool:    "lint",
+			wantErr: "tool must be in AUTHOR/ID[@VERSION] format",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := stubInstalledTools(t, "puppetlabs lint 0.10.0", "puppetlabs lint 0.9.0", "puppetlabs epp 0.1.0")
+
+			removed, err := p.UninstallTool(installedToolsPath, tt.tool)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantRemoved, toolVersions(removed))
+
+			remaining, _ := p.ListAllVersions(installedToolsPath)
+			assert.Equal(t, tt.wantRemaining, toolVersions(remaining))
+			for _, tool := range removed {
+				exists, _ := p.AFS.DirExists(tool.Path)
+				assert.False(t, exists)
+			}
+		})
+	}
+}
+
+func TestDocker_RemoveToolImages(t *testing.T) {
+	client := &mock.DockerClient{
+		ImagesSlice: []types.ImageSummary{
+			{ID: "lint-6", RepoTags: []string{"pdk:puppet-6.19.1_puppetlabs-lint_0.1.0"}},
+			{ID: "lint-7", RepoTags: []string{"localhost/pdk:puppet-7.15.0_puppetlabs-lint_0.1.0"}},
+			{ID: "lint-other-version", RepoTags: []string{"pdk:puppet-7.15.0_puppetlabs-lint_0.2.0"}},
+			{ID: "epp", RepoTags: []string{"pdk:puppet-7.15.0_puppetlabs-epp_0.1.0"}},
+		},
+	}
+	d := &prm.Docker{Client: client}
+
+	removed, err := d.RemoveToolImages(CreateToolInfo("lint", "puppetlabs", "0.1.0", nil).Tool)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pdk:puppet-6.19.1_puppetlabs-lint_0.1.0", "localhost/pdk:puppet-7.15.0_puppetlabs-lint_0.1.0"}, removed)
+	assert.Equal(t, []string{"lint-6", "lint-7"}, client.RemovedImages)
+}
+
+func TestIsToolAvailable(t *testing.T) {
+	tests := []struct {
+		name        string
+		tool        string
+		wantVersion string
+		wantErr     string
+	}{
+		{
+			name:        "Unpinned tool resolves to the newest version",
+			tool:        "puppetlabs/rubocop",
+			wantVersion: "1.3.0",
+		},
+		{
+			name:        "Exact version",
+			tool:        "puppetlabs/rubocop@1.2.0",
+			wantVersion: "1.2.0",
+		},
+		{
+			name:        "Constraint resolves to the newest matching version",
+			tool:        "puppetlabs/rubocop@~1.2",
+			wantVersion: "1.2.5",
+		},
+		{
+			name:    "No version satisfies the constraint",
+			tool:    "puppetlabs/rubocop@2.x",
+			wantErr: "no installed version of tool puppetlabs/rubocop satisfies '2.x'; installed versions: 1.2.0, 1.2.5, 1.3.0",
+		},
+		{
+			name:    "Invalid constraint",
+			tool:    "puppetlabs/rubocop@latest",
+			wantErr: "invalid version constraint 'latest' for tool puppetlabs/rubocop: improper constraint: latest",
+		},
+		{
+			name:    "Tool not installed",
+			tool:    "puppetlabs/epp@1.0.0",
+			wantErr: "Tool puppetlabs/epp not found in cache",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := stubInstalledTools(t, "puppetlabs rubocop 1.2.0", "puppetlabs rubocop 1.3.0", "puppetlabs rubocop 1.2.5")
+			assert.NoError(t, p.List(installedToolsPath, "", false))
+
+			tool, err := p.IsToolAvailable(tt.tool)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantVersion, tool.Cfg.Plugin.Version)
+		})
+	}
+}
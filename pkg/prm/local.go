@@ -0,0 +1,240 @@
+package prm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/afero"
+)
+
+const (
+	// Environment variable that exposes the PRM cache directory to tools
+	// run by the local backend, the equivalent of the /cache mount
+	LocalCacheDirEnvVar = "PRM_CACHE_DIR"
+)
+
+// Local runs gem and binary tools directly on the host, using the
+// puppet-agent's bundled Ruby when present and the host's PATH otherwise.
+type Local struct {
+	// We need to be able to mock process execution in testing
+	Runner         LocalRunnerI
+	ContextTimeout time.Duration
+	AFS            *afero.Afero
+}
+
+type LocalRunnerI interface {
+	// All process functions must be noted here so they can be mocked
+	LookPath(file string) (string, error)
+	Run(ctx context.Context, cmd LocalCommand, stdout io.Writer, stderr io.Writer) (int, error)
+}
+
+// LocalCommand describes a single process to be run on the host
+type LocalCommand struct {
+	Path string
+	Args []string
+	Dir  string
+	Env  []string
+}
+
+type localRunner struct{}
+
+func (localRunner) LookPath(file string) (string, error) {
+	return exec.LookPath(file)
+}
+
+// Run starts the process and waits for it to finish. A non-zero exit is not
+// treated as an error; the exit code is returned for the caller to interpret.
+func (localRunner) Run(ctx context.Context, cmd LocalCommand, stdout io.Writer, stderr io.Writer) (int, error) {
+	c := exec.CommandContext(ctx, cmd.Path, cmd.Args...) // #nosec G204 // running tools is the purpose of this backend
+	c.Dir = cmd.Dir
+	c.Env = cmd.Env
+	c.Stdout = stdout
+	c.Stderr = stderr
+
+	err := c.Run()
+	if ctx.Err() != nil {
+		return -1, ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return -1, err
+	}
+	return 0, nil
+}
+
+func (l *Local) GetTool(tool *Tool, prmConfig Config) error {
+	_, err := l.resolveExecutable(tool)
+	return err
+}
+
+func (l *Local) Validate(ctx context.Context, toolInfo ToolInfo, prmConfig Config, paths DirectoryPaths) (ValidateExitCode, ToolOutput, error) {
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+
+	exitCode, err := l.run(ctx, toolInfo, paths, stdout, stderr)
+	output := ToolOutput{Stdout: stdout.String(), Stderr: stderr.String(), ExitCode: exitCode}
+	if err != nil {
+		output.ExitCode = NoExitCode
+		return VALIDATION_ERROR, output, err
+	}
+
+	if exitCode == toolInfo.Tool.Cfg.Common.SuccessExitCode {
+		return VALIDATION_PASS, output, nil
+	}
+	return VALIDATION_FAILED, output, fmt.Errorf("%s", stderr.String())
+}
+
+func (l *Local) Exec(ctx context.Context, tool *Tool, args []string, prmConfig Config, paths DirectoryPaths) (ToolExitCode, error) {
+	log.Info().Msgf("Additional Args: %v", args)
+
+	exitCode, err := l.run(ctx, ToolInfo{Tool: tool, Args: args}, paths, os.Stdout, os.Stderr)
+	if err != nil {
+		return FAILURE, err
+	}
+
+	if exitCode == tool.Cfg.Common.SuccessExitCode {
+		return SUCCESS, nil
+	}
+	return TOOL_ERROR, fmt.Errorf("Tool exited with code: %d", exitCode)
+}
+
+// The local backend is always available, so the status reports
+// which Ruby runtime tools will be run with
+func (l *Local) Status() BackendStatus {
+	l.initRunner()
+
+	ruby := "not found"
+	if path, err := l.Runner.LookPath(filepath.Join(puppetAgentBinDir(), "ruby")); err == nil {
+		ruby = path
+	} else if path, err := l.Runner.LookPath("ruby"); err == nil {
+		ruby = path
+	}
+
+	status := fmt.Sprintf("\tPlatform: %s/%s\n\tRuby: %s", runtime.GOOS, runtime.GOARCH, ruby)
+	return BackendStatus{
+		IsAvailable: true,
+		StatusMsg:   status,
+	}
+}
+
+func (l *Local) run(ctx context.Context, toolInfo ToolInfo, paths DirectoryPaths, stdout io.Writer, stderr io.Writer) (int, error) {
+	tool, args := toolInfo.Tool, toolInfo.Args
+	executable, err := l.resolveExecutable(tool)
+	if err != nil {
+		return -1, err
+	}
+
+	codeDir, _ := filepath.Abs(paths.codeDir)
+	log.Debug().Msgf("Code path: %s", codeDir)
+	cacheDir, _ := filepath.Abs(paths.cacheDir)
+	log.Debug().Msgf("Cache path: %s", cacheDir)
+
+	// args can override the default args, as they do the default CMD of an image
+	if len(args) == 0 {
+		args = tool.Cfg.Common.DefaultArgs
+	}
+
+	env := os.Environ()
+	for key, val := range tool.Cfg.Common.Env {
+		env = append(env, fmt.Sprintf("%s=%s", key, val))
+	}
+	for key, val := range toolInfo.Env {
+		env = append(env, fmt.Sprintf("%s=%s", key, val))
+	}
+	env = append(env, fmt.Sprintf("%s=%s", LocalCacheDirEnvVar, cacheDir))
+
+	if toolInfo.Cpus > 0 || toolInfo.Memory != "" || tool.Cfg.Common.Cpus > 0 || tool.Cfg.Common.Memory != "" {
+		log.Warn().Msgf("The local backend does not limit the cpus or memory of %s", tool.Cfg.Plugin.Id)
+	}
+
+	timeout := toolInfo.timeout()
+	if timeout <= 0 {
+		timeout = l.ContextTimeout
+	}
+	if timeout <= 0 {
+		timeout = time.Duration(DefaultToolTimeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	log.Debug().Msgf("Running %s %v", executable, args)
+	return l.Runner.Run(ctx, LocalCommand{Path: executable, Args: args, Dir: codeDir, Env: env}, stdout, stderr)
+}
+
+// Works out which executable on the host runs the tool
+func (l *Local) resolveExecutable(tool *Tool) (string, error) {
+	l.initRunner()
+	toolName := fmt.Sprintf("%s/%s", tool.Cfg.Plugin.Author, tool.Cfg.Plugin.Id)
+
+	if tool.Cfg.Common.UseScript != "" {
+		script := filepath.Join(tool.Cfg.Path, "content", tool.Cfg.Common.UseScript+".sh")
+		if _, err := l.AFS.Stat(script); err != nil {
+			return "", fmt.Errorf("unable to find script '%s' for tool %s: %s", script, toolName, err)
+		}
+		return script, nil
+	}
+
+	if tool.Cfg.Gem != nil {
+		// Prefer gems installed into the puppet-agent's Ruby, then the host's Ruby
+		for _, candidate := range []string{filepath.Join(puppetAgentBinDir(), tool.Cfg.Gem.Executable), tool.Cfg.Gem.Executable} {
+			if path, err := l.Runner.LookPath(candidate); err == nil {
+				return path, nil
+			}
+		}
+		return "", fmt.Errorf("unable to find '%s' for tool %s on the host, install it with: gem install %s", tool.Cfg.Gem.Executable, toolName, strings.Join(tool.Cfg.Gem.Name, " "))
+	}
+
+	if tool.Cfg.Binary != nil {
+		if path, err := l.Runner.LookPath(tool.Cfg.Binary.Name); err == nil {
+			return path, nil
+		}
+		msg := fmt.Sprintf("unable to find '%s' for tool %s on the PATH", tool.Cfg.Binary.Name, toolName)
+		if steps := installStepsForOS(tool.Cfg.Binary.InstallSteps); steps != "" {
+			msg = fmt.Sprintf("%s, install it with:\n%s", msg, steps)
+		}
+		return "", fmt.Errorf("%s", msg)
+	}
+
+	return "", fmt.Errorf("tool %s cannot be run by the local backend as it is neither a gem nor a binary tool", toolName)
+}
+
+func (l *Local) initRunner() {
+	if l.Runner == nil {
+		l.Runner = localRunner{}
+	}
+}
+
+func installStepsForOS(steps *InstallSteps) string {
+	if steps == nil {
+		return ""
+	}
+	switch runtime.GOOS {
+	case "windows":
+		return steps.Windows
+	case "darwin":
+		return steps.Darwin
+	default:
+		return steps.Linux
+	}
+}
+
+// The bin directory of the puppet-agent package, which ships its own Ruby
+func puppetAgentBinDir() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("ProgramFiles"), "Puppet Labs", "Puppet", "puppet", "bin")
+	}
+	return "/opt/puppetlabs/puppet/bin"
+}
@@ -0,0 +1,180 @@
+package prm_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/puppetlabs/prm/internal/pkg/mock"
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocal_GetTool(t *testing.T) {
+	tests := []struct {
+		name     string
+		runner   mock.LocalRunner
+		cfg      prm.ToolConfig
+		errorMsg string
+	}{
+		{
+			name:   "Gem found in the puppet-agent",
+			runner: mock.LocalRunner{Executables: map[string]string{"/opt/puppetlabs/puppet/bin/rubocop": "/opt/puppetlabs/puppet/bin/rubocop"}},
+			cfg:    prm.ToolConfig{Gem: &prm.GemConfig{Name: []string{"rubocop"}, Executable: "rubocop"}},
+		},
+		{
+			name:   "Gem found in the host Ruby",
+			runner: mock.LocalRunner{Executables: map[string]string{"rubocop": "/usr/local/bin/rubocop"}},
+			cfg:    prm.ToolConfig{Gem: &prm.GemConfig{Name: []string{"rubocop"}, Executable: "rubocop"}},
+		},
+		{
+			name:     "Gem not installed",
+			cfg:      prm.ToolConfig{Gem: &prm.GemConfig{Name: []string{"rubocop", "rubocop-i18n"}, Executable: "rubocop"}},
+			errorMsg: "install it with: gem install rubocop rubocop-i18n",
+		},
+		{
+			name:   "Binary found on the PATH",
+			runner: mock.LocalRunner{Executables: map[string]string{"puppet-lint": "/usr/bin/puppet-lint"}},
+			cfg:    prm.ToolConfig{Binary: &prm.BinaryConfig{Name: "puppet-lint"}},
+		},
+		{
+			name:     "Binary not on the PATH",
+			cfg:      prm.ToolConfig{Binary: &prm.BinaryConfig{Name: "puppet-lint"}},
+			errorMsg: "unable to find 'puppet-lint'",
+		},
+		{
+			name:     "Container tools are not supported",
+			cfg:      prm.ToolConfig{Container: &prm.ContainerConfig{Name: "alpine", Tag: "latest"}},
+			errorMsg: "cannot be run by the local backend",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := CreateToolInfo("test", "user", "0.1.0", nil).Tool
+			tool.Cfg.Gem = tt.cfg.Gem
+			tool.Cfg.Binary = tt.cfg.Binary
+			tool.Cfg.Container = tt.cfg.Container
+
+			l := &prm.Local{Runner: &tt.runner, AFS: &afero.Afero{Fs: afero.NewMemMapFs()}}
+			err := l.GetTool(tool, prm.Config{PuppetVersion: semver.MustParse("7.15.0")})
+			if tt.errorMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.errorMsg)
+			}
+		})
+	}
+}
+
+func TestLocal_Validate(t *testing.T) {
+	tests := []struct {
+		name         string
+		runner       mock.LocalRunner
+		args         []string
+		want         prm.ValidateExitCode
+		wantErr      bool
+		wantStdout   string
+		wantStderr   string
+		wantExitCode int
+		wantArgs     []string
+	}{
+		{
+			name:       "Tool successfully validates with its default args",
+			runner:     mock.LocalRunner{Stdout: "all good"},
+			want:       prm.VALIDATION_PASS,
+			wantStdout: "all good",
+			wantArgs:   []string{"manifests"},
+		},
+		{
+			name:       "Tool args override the default args",
+			runner:     mock.LocalRunner{Stdout: "all good"},
+			args:       []string{"-l", "-v"},
+			want:       prm.VALIDATION_PASS,
+			wantStdout: "all good",
+			wantArgs:   []string{"-l", "-v"},
+		},
+		{
+			name:         "Tool returns a validation failure",
+			runner:       mock.LocalRunner{ExitCode: 1, Stdout: "1 offence", Stderr: "failed"},
+			want:         prm.VALIDATION_FAILED,
+			wantErr:      true,
+			wantStdout:   "1 offence",
+			wantStderr:   "failed",
+			wantExitCode: 1,
+			wantArgs:     []string{"manifests"},
+		},
+		{
+			name:         "Tool fails to start",
+			runner:       mock.LocalRunner{ErrorString: "permission denied"},
+			want:         prm.VALIDATION_ERROR,
+			wantErr:      true,
+			wantExitCode: prm.NoExitCode,
+			wantArgs:     []string{"manifests"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.runner.Executables = map[string]string{"puppet-lint": "/usr/bin/puppet-lint"}
+			toolInfo := CreateToolInfo("puppet-lint", "puppetlabs", "0.1.0", tt.args)
+			toolInfo.Tool.Cfg.Binary = &prm.BinaryConfig{Name: "puppet-lint"}
+			toolInfo.Tool.Cfg.Common.DefaultArgs = []string{"manifests"}
+			toolInfo.Tool.Cfg.Common.Env = map[string]string{"FOO": "bar"}
+
+			l := &prm.Local{Runner: &tt.runner, AFS: &afero.Afero{Fs: afero.NewMemMapFs()}}
+			got, output, err := l.Validate(context.Background(), toolInfo, prm.Config{}, prm.DirectoryPaths{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Local.Validate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantStdout, output.Stdout)
+			assert.Equal(t, tt.wantStderr, output.Stderr)
+			assert.Equal(t, tt.wantExitCode, output.ExitCode)
+			assert.Equal(t, "/usr/bin/puppet-lint", tt.runner.LastCommand.Path)
+			assert.Equal(t, tt.wantArgs, tt.runner.LastCommand.Args)
+			assert.Contains(t, tt.runner.LastCommand.Env, "FOO=bar")
+			cacheDir, _ := filepath.Abs("")
+			assert.Contains(t, tt.runner.LastCommand.Env, prm.LocalCacheDirEnvVar+"="+cacheDir)
+		})
+	}
+}
+
+func TestLocal_Exec(t *testing.T) {
+	tests := []struct {
+		name    string
+		runner  mock.LocalRunner
+		want    prm.ToolExitCode
+		wantErr bool
+	}{
+		{
+			name: "Tool exits with its success exit code",
+			want: prm.SUCCESS,
+		},
+		{
+			name:    "Tool exits with a different exit code",
+			runner:  mock.LocalRunner{ExitCode: 2},
+			want:    prm.TOOL_ERROR,
+			wantErr: true,
+		},
+		{
+			name:    "Tool fails to start",
+			runner:  mock.LocalRunner{ErrorString: "permission denied"},
+			want:    prm.FAILURE,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.runner.Executables = map[string]string{"puppet-lint": "/usr/bin/puppet-lint"}
+			tool := CreateToolInfo("puppet-lint", "puppetlabs", "0.1.0", nil).Tool
+			tool.Cfg.Binary = &prm.BinaryConfig{Name: "puppet-lint"}
+
+			l := &prm.Local{Runner: &tt.runner, AFS: &afero.Afero{Fs: afero.NewMemMapFs()}}
+			got, err := l.Exec(context.Background(), tool, nil, prm.Config{}, prm.DirectoryPaths{})
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package prm
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+const LockFileName = "prm.lock"
+
+// Lockfile records the environment validation was last locked to
+type Lockfile struct {
+	PuppetVersion string        `yaml:"puppet_version"`
+	Backend       BackendType   `yaml:"backend"`
+	Groups        []LockedGroup `yaml:"groups"`
+}
+
+type LockedGroup struct {
+	ID    string       `yaml:"id"`
+	Tools []LockedTool `yaml:"tools"`
+}
+
+type LockedTool struct {
+	// The name as given in validate.yml, including any version pin
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+	// The image the tool runs in for each Puppet version the group validates against
+	Images map[string]LockedImage `yaml:"images,omitempty"`
+}
+
+type LockedImage struct {
+	Image   string `yaml:"image"`
+	ImageID string `yaml:"image_id,omitempty"`
+}
+
+// ToolImageInspectorI is implemented by backends that run tools in images
+type ToolImageInspectorI interface {
+	// Returns the name and ID of the image the tool runs in,
+	// with an empty ID if the image does not exist yet
+	ToolImage(tool *Tool, prmConfig Config) (name string, id string, err error)
+}
+
+// CreateLock resolves every tool in each validate.yml group, readying the
+// backend images for them against each Puppet version of the group, and
+// records the result
+func (p *Prm) CreateLock() (Lockfile, error) {
+	validateFile, err := p.getValidateFilePath()
+	if err != nil {
+		return Lockfile{}, err
+	}
+	groups, err := p.getGroupsFromFile(validateFile)
+	if err != nil {
+		return Lockfile{}, err
+	}
+
+	lock := Lockfile{PuppetVersion: p.RunningConfig.PuppetVersion.String(), Backend: p.RunningConfig.Backend}
+	// The images readied so far, by tool name and Puppet version
+	readied := make(map[string]LockedImage)
+	for _, group := range groups {
+		lockedGroup := LockedGroup{ID: group.ID}
+		resolved, err := resolveGroup(withBuiltinGroups(groups), group)
+		if err != nil {
+			return Lockfile{}, err
+		}
+		versions, err := p.lockPuppetVersions(resolved)
+		if err != nil {
+			return Lockfile{}, err
+		}
+		inGroup := make(map[string]bool)
+		for _, toolInst := range resolved.Tools {
+			// A built-in group may run the same tool more than once
+			if inGroup[toolInst.Name] {
+				continue
+			}
+			inGroup[toolInst.Name] = true
+			lockedTool, err := p.lockTool(toolInst.Name, versions, readied)
+			if err != nil {
+				return Lockfile{}, err
+			}
+			lockedGroup.Tools = append(lockedGroup.Tools, lockedTool)
+		}
+		lock.Groups = append(lock.Groups, lockedGroup)
+	}
+	return lock, nil
+}
+
+// The Puppet versions a group validates against: its puppet_versions,
+// or else the configured version
+func (p *Prm) lockPuppetVersions(group Group) ([]*semver.Version, error) {
+	if len(group.PuppetVersions) == 0 {
+		return []*semver.Version{p.RunningConfig.PuppetVersion}, nil
+	}
+
+	var versions []*semver.Version
+	seen := make(map[string]bool)
+	for _, spec := range group.PuppetVersions {
+		parsed, err := ParsePuppetVersions(spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid puppet_versions for the tool group '%s': %s", group.ID, err)
+		}
+		for _, version := range parsed {
+			if !seen[version.String()] {
+				seen[version.String()] = true
+				versions = append(versions, version)
+			}
+		}
+	}
+	return versions, nil
+}
+
+func (p *Prm) lockTool(name string, versions []*semver.Version, readied map[string]LockedImage) (LockedTool, error) {
+	tool, err := p.IsToolAvailable(name)
+	if err != nil {
+		return LockedTool{}, err
+	}
+
+	lockedTool := LockedTool{Name: name, Version: tool.Cfg.Plugin.Version}
+	inspector, ok := p.Backend.(ToolImageInspectorI)
+	if !ok {
+		return lockedTool, nil
+	}
+
+	lockedTool.Images = make(map[string]LockedImage)
+	for _, version := range versions {
+		key := name + "\x00" + version.String()
+		image, ok := readied[key]
+		if !ok {
+			config := p.RunningConfig
+			config.PuppetVersion = version
+			err = p.Backend.GetTool(tool, config)
+			if err != nil {
+				return LockedTool{}, fmt.Errorf("unable to ready %s for Puppet %s: %s", name, version, err)
+			}
+			image.Image, image.ImageID, err = inspector.ToolImage(tool, config)
+			if err != nil {
+				return LockedTool{}, err
+			}
+			readied[key] = image
+		}
+		lockedTool.Images[version.String()] = image
+	}
+	return lockedTool, nil
+}
+
+// WriteLock writes the lockfile to the codedir
+func (p *Prm) WriteLock(lock Lockfile) (string, error) {
+	content, err := yaml.Marshal(lock)
+	if err != nil {
+		return "", err
+	}
+	lockFile := filepath.Join(p.CodeDir, LockFileName)
+	err = p.AFS.WriteFile(lockFile, content, 0644)
+	if err != nil {
+		return "", fmt.Errorf("unable to write %s: %s", lockFile, err)
+	}
+	return lockFile, nil
+}
+
+// ReadLock reads the codedir's lockfile, returning nil if there is none
+func (p *Prm) ReadLock() (*Lockfile, error) {
+	lockFile := filepath.Join(p.CodeDir, LockFileName)
+	if exists, _ := p.AFS.Exists(lockFile); !exists {
+		return nil, nil
+	}
+	content, err := p.AFS.ReadFile(lockFile)
+	if err != nil {
+		return nil, err
+	}
+	var lock Lockfile
+	err = yaml.Unmarshal(content, &lock)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not formatted correctly: %s", LockFileName, err)
+	}
+	return &lock, nil
+}
+
+// CheckLock compares the tools about to be run against the codedir's lockfile,
+// if there is one, returning how the environment differs from it.
+// With no groupID the tools are looked up in every locked group,
+// and tools that are not locked are ignored.
+func (p *Prm) CheckLock(groupID string, toolsInfo []ToolInfo) ([]string, error) {
+	lock, err := p.ReadLock()
+	if err != nil || lock == nil {
+		return nil, err
+	}
+
+	var mismatches []string
+	if p.RunningConfig.PuppetVersion != nil && lock.PuppetVersion != p.RunningConfig.PuppetVersion.String() {
+		mismatches = append(mismatches, fmt.Sprintf("Puppet %s is configured but %s is locked", p.RunningConfig.PuppetVersion, lock.PuppetVersion))
+	}
+	if lock.Backend != "" && lock.Backend != p.RunningConfig.Backend {
+		mismatches = append(mismatches, fmt.Sprintf("the %s backend is configured but %s is locked", p.RunningConfig.Backend, lock.Backend))
+	}
+
+	inspector, _ := p.Backend.(ToolImageInspectorI)
+	for _, info := range toolsInfo {
+		name := info.Tool.Cfg.Plugin.Author + "/" + info.Tool.Cfg.Plugin.Id
+		lockedTool, ok := lock.findTool(groupID, name)
+		if !ok {
+			// Only the tools of a locked group are expected to be locked
+			if groupID != "" {
+				mismatches = append(mismatches, fmt.Sprintf("%s is not locked", name))
+			}
+			continue
+		}
+		if lockedTool.Version != info.Tool.Cfg.Plugin.Version {
+			mismatches = append(mismatches, fmt.Sprintf("%s %s resolved but %s is locked", name, info.Tool.Cfg.Plugin.Version, lockedTool.Version))
+			continue
+		}
+		if inspector == nil || len(lockedTool.Images) == 0 {
+			continue
+		}
+		// Each run is checked against the image locked for its own Puppet version
+		config := p.toolConfig(info)
+		puppetVersion := ""
+		if config.PuppetVersion != nil {
+			puppetVersion = config.PuppetVersion.String()
+		}
+		lockedImage, ok := lockedTool.Images[puppetVersion]
+		if !ok {
+			mismatches = append(mismatches, fmt.Sprintf("%s is not locked for Puppet %s", name, puppetVersion))
+			continue
+		}
+		if lockedImage.ImageID == "" {
+			continue
+		}
+		image, imageID, err := inspector.ToolImage(info.Tool, config)
+		if err != nil {
+			log.Debug().Msgf("Unable to inspect the image for %s: %s", name, err)
+			continue
+		}
+		if imageID != lockedImage.ImageID {
+			mismatches = append(mismatches, fmt.Sprintf("image %s for %s differs from the locked image %s", image, name, lockedImage.ImageID))
+		}
+	}
+	return mismatches, nil
+}
+
+func (l *Lockfile) findTool(groupID string, name string) (LockedTool, bool) {
+	for _, group := range l.Groups {
+		if groupID != "" && group.ID != groupID {
+			continue
+		}
+		for _, tool := range group.Tools {
+			lockedName, _, _ := strings.Cut(tool.Name, "@")
+			if lockedName == name {
+				return tool, true
+			}
+		}
+	}
+	return LockedTool{}, false
+}
+
+// LockError reports how the environment differs from the lockfile
+func LockError(mismatches []string) error {
+	return fmt.Errorf("the environment no longer matches %s:\n  - %s\nRun 'prm lock' to update it", LockFileName, strings.Join(mismatches, "\n  - "))
+}
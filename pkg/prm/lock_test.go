@@ -0,0 +1,143 @@
+package prm_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/docker/docker/api/types"
+	"github.com/puppetlabs/prm/internal/pkg/mock"
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrm_Lock(t *testing.T) {
+	p := stubInstalledTools(t, "puppetlabs lint 0.1.0", "puppetlabs lint 0.2.0", "puppetlabs epp 0.1.0")
+	p.CodeDir = "path/to/code"
+	p.AFS.MkdirAll(p.CodeDir, 0750) //nolint:errcheck
+	validateYml := `groups:
+  - id: ci
+    tools:
+      - name: puppetlabs/lint@~0.1
+      - name: puppetlabs/epp
+  - id: quick
+    tools:
+      - name: puppetlabs/epp
+`
+	p.AFS.WriteFile(filepath.Join(p.CodeDir, "validate.yml"), []byte(validateYml), 0644) //nolint:errcheck
+	assert.NoError(t, p.List(installedToolsPath, "", false))
+
+	client := &mock.DockerClient{
+		ImagesSlice: []types.ImageSummary{
+			{ID: "sha256:lint", RepoTags: []string{"pdk:puppet-7.15.0_puppetlabs-lint_0.1.0"}},
+			{ID: "sha256:epp", RepoTags: []string{"pdk:puppet-7.15.0_puppetlabs-epp_0.1.0"}},
+		},
+	}
+	p.Backend = &prm.Docker{Client: client}
+	p.RunningConfig = prm.Config{PuppetVersion: semver.MustParse("7.15.0"), Backend: prm.DOCKER}
+
+	lock, err := p.CreateLock()
+	assert.NoError(t, err)
+	assert.Equal(t, prm.Lockfile{
+		PuppetVersion: "7.15.0",
+		Backend:       prm.DOCKER,
+		Groups: []prm.LockedGroup{
+			{ID: "ci", Tools: []prm.LockedTool{
+				{Name: "puppetlabs/lint@~0.1", Version: "0.1.0", Images: map[string]prm.LockedImage{"7.15.0": {Image: "pdk:puppet-7.15.0_puppetlabs-lint_0.1.0", ImageID: "sha256:lint"}}},
+				{Name: "puppetlabs/epp", Version: "0.1.0", Images: map[string]prm.LockedImage{"7.15.0": {Image: "pdk:puppet-7.15.0_puppetlabs-epp_0.1.0", ImageID: "sha256:epp"}}},
+			}},
+			{ID: "quick", Tools: []prm.LockedTool{
+				{Name: "puppetlabs/epp", Version: "0.1.0", Images: map[string]prm.LockedImage{"7.15.0": {Image: "pdk:puppet-7.15.0_puppetlabs-epp_0.1.0", ImageID: "sha256:epp"}}},
+			}},
+		},
+	}, lock)
+
+	lockFile, err := p.WriteLock(lock)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(p.CodeDir, prm.LockFileName), lockFile)
+	read, err := p.ReadLock()
+	assert.NoError(t, err)
+	assert.Equal(t, &lock, read)
+
+	lint, _ := p.IsToolAvailable("puppetlabs/lint@~0.1")
+	newLint, _ := p.IsToolAvailable("puppetlabs/lint")
+	epp, _ := p.IsToolAvailable("puppetlabs/epp")
+
+	mismatches, err := p.CheckLock("ci", []prm.ToolInfo{{Tool: lint}, {Tool: epp}})
+	assert.NoError(t, err)
+	assert.Empty(t, mismatches)
+
+	mismatches, _ = p.CheckLock("ci", []prm.ToolInfo{{Tool: newLint}})
+	assert.Equal(t, []string{"puppetlabs/lint 0.2.0 resolved but 0.1.0 is locked"}, mismatches)
+
+	mismatches, _ = p.CheckLock("quick", []prm.ToolInfo{{Tool: lint}})
+	assert.Equal(t, []string{"puppetlabs/lint is not locked"}, mismatches)
+
+	// Tools run outside of a group only need to match if they are locked
+	mismatches, _ = p.CheckLock("", []prm.ToolInfo{{Tool: lint}})
+	assert.Empty(t, mismatches)
+
+	client.ImagesSlice[1].ID = "sha256:rebuilt"
+	mismatches, _ = p.CheckLock("ci", []prm.ToolInfo{{Tool: epp}})
+	assert.Equal(t, []string{"image pdk:puppet-7.15.0_puppetlabs-epp_0.1.0 for puppetlabs/epp differs from the locked image sha256:epp"}, mismatches)
+
+	p.RunningConfig.PuppetVersion = semver.MustParse("6.19.1")
+	mismatches, _ = p.CheckLock("quick", nil)
+	assert.Equal(t, []string{"Puppet 6.19.1 is configured but 7.15.0 is locked"}, mismatches)
+}
+
+func TestPrm_Lock_PuppetMatrix(t *testing.T) {
+	p := stubInstalledTools(t, "puppetlabs epp 0.1.0")
+	p.CodeDir = "path/to/code"
+	p.AFS.MkdirAll(p.CodeDir, 0750) //nolint:errcheck
+	validateYml := `groups:
+  - id: ci
+    puppet_versions: ["6.19.1", "7.15.0"]
+    tools:
+      - name: puppetlabs/epp
+`
+	p.AFS.WriteFile(filepath.Join(p.CodeDir, "validate.yml"), []byte(validateYml), 0644) //nolint:errcheck
+	assert.NoError(t, p.List(installedToolsPath, "", false))
+
+	client := &mock.DockerClient{
+		ImagesSlice: []types.ImageSummary{
+			{ID: "sha256:epp6", RepoTags: []string{"pdk:puppet-6.19.1_puppetlabs-epp_0.1.0"}},
+			{ID: "sha256:epp7", RepoTags: []string{"pdk:puppet-7.15.0_puppetlabs-epp_0.1.0"}},
+		},
+	}
+	p.Backend = &prm.Docker{Client: client}
+	p.RunningConfig = prm.Config{PuppetVersion: semver.MustParse("7.15.0"), Backend: prm.DOCKER}
+
+	lock, err := p.CreateLock()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]prm.LockedImage{
+		"6.19.1": {Image: "pdk:puppet-6.19.1_puppetlabs-epp_0.1.0", ImageID: "sha256:epp6"},
+		"7.15.0": {Image: "pdk:puppet-7.15.0_puppetlabs-epp_0.1.0", ImageID: "sha256:epp7"},
+	}, lock.Groups[0].Tools[0].Images)
+	_, err = p.WriteLock(lock)
+	assert.NoError(t, err)
+
+	epp, _ := p.IsToolAvailable("puppetlabs/epp")
+	matrix := prm.ExpandPuppetMatrix([]prm.ToolInfo{{Tool: epp}}, []*semver.Version{semver.MustParse("6.19.1"), semver.MustParse("7.15.0")})
+
+	// Each run matches the image locked for its own Puppet version
+	mismatches, err := p.CheckLock("ci", matrix)
+	assert.NoError(t, err)
+	assert.Empty(t, mismatches)
+
+	client.ImagesSlice[0].ID = "sha256:rebuilt"
+	mismatches, _ = p.CheckLock("ci", matrix)
+	assert.Equal(t, []string{"image pdk:puppet-6.19.1_puppetlabs-epp_0.1.0 for puppetlabs/epp differs from the locked image sha256:epp6"}, mismatches)
+
+	mismatches, _ = p.CheckLock("ci", prm.ExpandPuppetMatrix([]prm.ToolInfo{{Tool: epp}}, []*semver.Version{semver.MustParse("5.5.22")}))
+	assert.Equal(t, []string{"puppetlabs/epp is not locked for Puppet 5.5.22"}, mismatches)
+}
+
+func TestPrm_CheckLock_NoLockfile(t *testing.T) {
+	p := stubInstalledTools(t)
+	p.CodeDir = "path/to/code"
+
+	mismatches, err := p.CheckLock("ci", []prm.ToolInfo{CreateToolInfo("lint", "puppetlabs", "0.1.0", nil)})
+	assert.NoError(t, err)
+	assert.Empty(t, mismatches)
+}
@@ -0,0 +1,108 @@
+package prm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/Masterminds/semver"
+)
+
+const (
+	MetadataFileName = "metadata.json"
+)
+
+var (
+	// Puppet versions with published puppet/puppet-agent images
+	// which can be chosen to satisfy a module's requirements
+	KnownPuppetVersions = []string{"5.5.22", "6.19.1", DefaultPuppetVer}
+
+	// Module requirements separate bounds with spaces, e.g. ">= 6.0.0 < 8.0.0",
+	// whereas the semver library expects them separated by commas
+	requirementBoundRegex = regexp.MustCompile(`([\d.xX*]+)\s+([<>=!~^])`)
+)
+
+type moduleMetadata struct {
+	Requirements []moduleRequirement `json:"requirements"`
+}
+
+type moduleRequirement struct {
+	Name               string `json:"name"`
+	VersionRequirement string `json:"version_requirement"`
+}
+
+// What version of Puppet is requested by the module's metadata.json.
+// Returns a nil version when the code dir declares no Puppet requirement,
+// otherwise the version chosen and the reason it was chosen.
+func (p *Prm) getPuppetVersion() (*semver.Version, string, error) {
+	if p.AFS == nil {
+		return nil, "", nil
+	}
+
+	metadataFile := filepath.Join(p.codeDirOrWorkingDir(), MetadataFileName)
+	if exists, _ := p.AFS.Exists(metadataFile); !exists {
+		return nil, "", nil
+	}
+
+	content, err := p.AFS.ReadFile(metadataFile)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var metadata moduleMetadata
+	err = json.Unmarshal(content, &metadata)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to parse %s: %s", metadataFile, err)
+	}
+
+	for _, requirement := range metadata.Requirements {
+		if requirement.Name != "puppet" || requirement.VersionRequirement == "" {
+			continue
+		}
+
+		version, err := resolvePuppetRequirement(requirement.VersionRequirement)
+		if err != nil {
+			return nil, "", fmt.Errorf("unable to resolve the puppet requirement in %s: %s", metadataFile, err)
+		}
+		return version, fmt.Sprintf("it satisfies the puppet requirement '%s' in %s", requirement.VersionRequirement, metadataFile), nil
+	}
+
+	return nil, "", nil
+}
+
+// Chooses the Puppet version for a requirement, preferring the default
+// version and otherwise the newest known version that satisfies it
+func resolvePuppetRequirement(requirement string) (*semver.Version, error) {
+	constraint, err := semver.NewConstraint(requirementBoundRegex.ReplaceAllString(requirement, "$1, $2"))
+	if err != nil {
+		return nil, err
+	}
+
+	if defaultVersion := semver.MustParse(DefaultPuppetVer); constraint.Check(defaultVersion) {
+		return defaultVersion, nil
+	}
+
+	versions := make([]*semver.Version, len(KnownPuppetVersions))
+	for i, raw := range KnownPuppetVersions {
+		versions[i] = semver.MustParse(raw)
+	}
+	sort.Sort(sort.Reverse(semver.Collection(versions)))
+
+	for _, version := range versions {
+		if constraint.Check(version) {
+			return version, nil
+		}
+	}
+	return nil, fmt.Errorf("no known Puppet version satisfies '%s', use 'prm set puppet' to choose one", requirement)
+}
+
+func (p *Prm) codeDirOrWorkingDir() string {
+	if p.CodeDir != "" {
+		return p.CodeDir
+	}
+	workingDirectory, _ := os.Getwd()
+	return workingDirectory
+}
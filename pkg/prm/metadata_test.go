@@ -0,0 +1,83 @@
+package prm_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfig_MetadataPuppetVersion(t *testing.T) {
+	tests := []struct {
+		name              string
+		metadata          string
+		configuredVersion string
+		wantPuppetVersion string
+	}{
+		{
+			name:              "No metadata.json uses the default version",
+			wantPuppetVersion: prm.DefaultPuppetVer,
+		},
+		{
+			name:              "No puppet requirement uses the default version",
+			metadata:          `{"name": "puppetlabs-motd", "requirements": [{"name": "pe", "version_requirement": ">= 2019.8.0"}]}`,
+			wantPuppetVersion: prm.DefaultPuppetVer,
+		},
+		{
+			name:              "Requirement satisfied by the default version",
+			metadata:          `{"requirements": [{"name": "puppet", "version_requirement": ">= 6.0.0 < 8.0.0"}]}`,
+			wantPuppetVersion: prm.DefaultPuppetVer,
+		},
+		{
+			name:              "Requirement for an older major version",
+			metadata:          `{"requirements": [{"name": "puppet", "version_requirement": ">= 6.0.0 < 7.0.0"}]}`,
+			wantPuppetVersion: "6.19.1",
+		},
+		{
+			name:              "Requirement for Puppet 5",
+			metadata:          `{"requirements": [{"name": "puppet", "version_requirement": ">=5.5.0 <6.0.0"}]}`,
+			wantPuppetVersion: "5.5.22",
+		},
+		{
+			name:              "Requirement no known version satisfies uses the default version",
+			metadata:          `{"requirements": [{"name": "puppet", "version_requirement": ">= 9.0.0"}]}`,
+			wantPuppetVersion: prm.DefaultPuppetVer,
+		},
+		{
+			name:              "Invalid metadata.json uses the default version",
+			metadata:          `{"requirements": `,
+			wantPuppetVersion: prm.DefaultPuppetVer,
+		},
+		{
+			name:              "Configured version overrides the requirement",
+			metadata:          `{"requirements": [{"name": "puppet", "version_requirement": ">= 6.0.0 < 7.0.0"}]}`,
+			configuredVersion: "7.10.0",
+			wantPuppetVersion: "7.10.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			defer viper.Reset()
+
+			afs := &afero.Afero{Fs: afero.NewMemMapFs()}
+			afs.MkdirAll("/code", 0755) //nolint:gosec,errcheck
+			if tt.metadata != "" {
+				afs.WriteFile("/code/metadata.json", []byte(tt.metadata), 0644) //nolint:gosec,errcheck
+			}
+
+			prmObj := &prm.Prm{AFS: afs, CodeDir: "/code"}
+			prmObj.GenerateDefaultCfg()
+			if tt.configuredVersion != "" {
+				viper.MergeConfigMap(map[string]interface{}{prm.PuppetVerCfgKey: tt.configuredVersion}) //nolint:errcheck
+			}
+
+			err := prmObj.LoadConfig()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantPuppetVersion, fmt.Sprint(prmObj.RunningConfig.PuppetVersion))
+		})
+	}
+}
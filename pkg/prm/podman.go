@@ -0,0 +1,180 @@
+package prm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	dockerClient "github.com/docker/docker/client"
+	"github.com/rs/zerolog/log"
+)
+
+// Podman runs tools through the Docker compatible API served on the
+// Podman socket, so the image build and container lifecycle is shared
+// with the Docker backend.
+type Podman struct {
+	Docker
+	// Location of the Podman API socket, e.g. unix:///run/podman/podman.sock.
+	// When empty the socket is located via $CONTAINER_HOST or the
+	// default rootless/rootful socket paths.
+	SocketPath string
+}
+
+var (
+	ErrPodmanNotRunning = fmt.Errorf("podman is not running, please start the podman socket service (systemctl --user start podman.socket)")
+)
+
+func (p *Podman) GetTool(tool *Tool, prmConfig Config) error {
+	err := p.initClient()
+	if err != nil {
+		return err
+	}
+	return p.Docker.GetTool(tool, prmConfig)
+}
+
+func (p *Podman) Validate(ctx context.Context, toolInfo ToolInfo, prmConfig Config, paths DirectoryPaths) (ValidateExitCode, ToolOutput, error) {
+	err := p.checkAvailable()
+	if err != nil {
+		return VALIDATION_ERROR, ToolOutput{ExitCode: NoExitCode}, err
+	}
+	return p.Docker.Validate(ctx, toolInfo, prmConfig, paths)
+}
+
+func (p *Podman) Exec(ctx context.Context, tool *Tool, args []string, prmConfig Config, paths DirectoryPaths) (ToolExitCode, error) {
+	err := p.checkAvailable()
+	if err != nil {
+		return FAILURE, err
+	}
+	return p.Docker.Exec(ctx, tool, args, prmConfig, paths)
+}
+
+// Checks Podman is up and running before running a tool, so
+// a stopped socket is not reported as Docker being unavailable
+func (p *Podman) checkAvailable() error {
+	status := p.Status()
+	if status.IsAvailable {
+		return nil
+	}
+	log.Error().Msgf("Podman is not available")
+	if status.StatusMsg == ErrPodmanNotRunning.Error() {
+		return ErrPodmanNotRunning
+	}
+	return fmt.Errorf("%s", status.StatusMsg)
+}
+
+func (p *Podman) ToolImage(tool *Tool, prmConfig Config) (string, string, error) {
+	err := p.initClient()
+	if err != nil {
+		return "", "", err
+	}
+	return p.Docker.ToolImage(tool, prmConfig)
+}
+
+func (p *Podman) RemoveToolImages(tool *Tool) ([]string, error) {
+	err := p.initClient()
+	if err != nil {
+		return nil, err
+	}
+	return p.Docker.RemoveToolImages(tool)
+}
+
+func (p *Podman) ToolImages() ([]BackendResource, error) {
+	err := p.initClient()
+	if err != nil {
+		return nil, err
+	}
+	return p.Docker.ToolImages()
+}
+
+func (p *Podman) ToolContainers() ([]BackendResource, error) {
+	err := p.initClient()
+	if err != nil {
+		return nil, err
+	}
+	return p.Docker.ToolContainers()
+}
+
+func (p *Podman) RemoveImage(id string) error {
+	err := p.initClient()
+	if err != nil {
+		return err
+	}
+	return p.Docker.RemoveImage(id)
+}
+
+func (p *Podman) RemoveContainer(id string) error {
+	err := p.initClient()
+	if err != nil {
+		return err
+	}
+	return p.Docker.RemoveContainer(id)
+}
+
+// Check to see if the Podman service is available:
+// if so, return true and info about Podman on this node;
+// if not, return false and the error message
+func (p *Podman) Status() BackendStatus {
+	err := p.initClient()
+	if err != nil {
+		return BackendStatus{
+			IsAvailable: false,
+			StatusMsg:   fmt.Sprintf("unable to initialize the podman client: %s", err.Error()),
+		}
+	}
+
+	podmanInfo, err := p.Client.ServerVersion(p.Context)
+	if err != nil {
+		message := err.Error()
+		// Connection failures against the socket are almost always
+		// caused by the podman socket service not being started
+		if strings.Contains(message, "Cannot connect to the Docker daemon") || strings.Contains(message, "connection refused") || strings.Contains(message, "no such file or directory") {
+			message = ErrPodmanNotRunning.Error()
+		}
+		return BackendStatus{
+			IsAvailable: false,
+			StatusMsg:   message,
+		}
+	}
+	status := fmt.Sprintf("\tPlatform: %s\n\tVersion: %s\n\tAPI Version: %s", podmanInfo.Platform.Name, podmanInfo.Version, podmanInfo.APIVersion)
+	return BackendStatus{
+		IsAvailable: true,
+		StatusMsg:   status,
+	}
+}
+
+func (p *Podman) initClient() error {
+	if p.Client == nil {
+		cli, err := dockerClient.NewClientWithOpts(
+			dockerClient.WithHost(p.socketHost()),
+			dockerClient.WithAPIVersionNegotiation(),
+		)
+		if err != nil {
+			return err
+		}
+
+		p.Client = cli
+		p.Context = context.Background()
+		p.ContextCancel = nil
+	}
+	return nil
+}
+
+// Works out which socket to talk to, preferring an explicitly configured
+// path, then $CONTAINER_HOST, then the rootless and rootful defaults
+func (p *Podman) socketHost() string {
+	if p.SocketPath != "" {
+		return p.SocketPath
+	}
+
+	if host := os.Getenv("CONTAINER_HOST"); host != "" {
+		return host
+	}
+
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" && os.Geteuid() != 0 {
+		return "unix://" + filepath.Join(runtimeDir, "podman", "podman.sock")
+	}
+
+	return "unix:///run/podman/podman.sock"
+}
@@ -0,0 +1,147 @@
+package prm_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/docker/docker/api/types"
+	"github.com/puppetlabs/prm/internal/pkg/mock"
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPodman_Status(t *testing.T) {
+	tests := []struct {
+		name       string
+		mockClient mock.DockerClient
+		want       prm.BackendStatus
+	}{
+		{
+			name: "When the podman socket is not listening",
+			mockClient: mock.DockerClient{
+				ErrorString: "Cannot connect to the Docker daemon at unix:///run/user/1000/podman/podman.sock. Is the docker daemon running?",
+			},
+			want: prm.BackendStatus{
+				IsAvailable: false,
+				StatusMsg:   prm.ErrPodmanNotRunning.Error(),
+			},
+		},
+		{
+			name: "When an edge case failure occurs",
+			mockClient: mock.DockerClient{
+				ErrorString: "Something has gone terribly wrong!",
+			},
+			want: prm.BackendStatus{
+				IsAvailable: false,
+				StatusMsg:   "Something has gone terribly wrong!",
+			},
+		},
+		{
+			name: "When everything is working",
+			mockClient: mock.DockerClient{
+				Platform:   "Podman Engine",
+				Version:    "4.1.1",
+				ApiVersion: "1.40",
+			},
+			want: prm.BackendStatus{
+				IsAvailable: true,
+				StatusMsg:   "\tPlatform: Podman Engine\n\tVersion: 4.1.1\n\tAPI Version: 1.40",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &prm.Podman{Docker: prm.Docker{Client: &tt.mockClient}}
+			if got := p.Status(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Podman.Status() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPodman_GetTool(t *testing.T) {
+	tests := []struct {
+		name       string
+		mockClient mock.DockerClient
+	}{
+		{
+			name: "Image found with the localhost registry prefix",
+			mockClient: mock.DockerClient{
+				ImagesSlice: []types.ImageSummary{
+					{
+						RepoTags: []string{"localhost/pdk:puppet-7.15.0_user-test_0.1.0"},
+						ID:       "foo",
+					},
+				},
+			},
+		},
+		{
+			name: "Image not found and create new image",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			afs := &afero.Afero{Fs: fs}
+			p := &prm.Podman{Docker: prm.Docker{Client: &tt.mockClient, AFS: afs}}
+			toolInfo := CreateToolInfo("test", "user", "0.1.0", nil)
+			err := p.GetTool(toolInfo.Tool, prm.Config{PuppetVersion: semver.MustParse("7.15.0")})
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPodman_Validate(t *testing.T) {
+	tests := []struct {
+		name         string
+		mockClient   mock.DockerClient
+		want         prm.ValidateExitCode
+		wantStdout   string
+		wantExitCode int
+		wantErr      error
+	}{
+		{
+			name:       "Tool runs through the podman socket",
+			mockClient: mock.DockerClient{ExitCode: 0, Stdout: "podman stdout"},
+			want:       prm.VALIDATION_PASS,
+			wantStdout: "podman stdout",
+		},
+		{
+			name: "Podman socket is not listening",
+			mockClient: mock.DockerClient{
+				ErrorString: "Cannot connect to the Docker daemon at unix:///run/user/1000/podman/podman.sock. Is the docker daemon running?",
+			},
+			want:         prm.VALIDATION_ERROR,
+			wantExitCode: prm.NoExitCode,
+			wantErr:      prm.ErrPodmanNotRunning,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &prm.Podman{Docker: prm.Docker{Client: &tt.mockClient}}
+			toolInfo := CreateToolInfo("good-project", "test-user", "0.1.0", nil)
+
+			got, output, err := p.Validate(context.Background(), toolInfo, prm.Config{PuppetVersion: semver.MustParse("7.15.0")}, prm.DirectoryPaths{})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantStdout, output.Stdout)
+			assert.Equal(t, tt.wantExitCode, output.ExitCode)
+		})
+	}
+}
+
+func TestPodman_Exec_NotRunning(t *testing.T) {
+	p := &prm.Podman{Docker: prm.Docker{Client: &mock.DockerClient{ErrorString: "dial unix /run/podman/podman.sock: connect: connection refused"}}}
+	toolInfo := CreateToolInfo("good-project", "test-user", "0.1.0", nil)
+
+	got, err := p.Exec(context.Background(), toolInfo.Tool, nil, prm.Config{PuppetVersion: semver.MustParse("7.15.0")}, prm.DirectoryPaths{})
+	assert.ErrorIs(t, err, prm.ErrPodmanNotRunning)
+	assert.Equal(t, prm.FAILURE, got)
+}
@@ -8,7 +8,6 @@ import (
 	"sort"
 	"strings"
 
-	"github.com/Masterminds/semver"
 	"github.com/hashicorp/go-version"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/olekukonko/tablewriter"
@@ -31,15 +30,23 @@ type Prm struct {
 	CacheDir      string
 	Cache         map[string]*Tool
 	Backend       BackendI
-}
-
-type PuppetVersion struct {
-	version semver.Version
+	// Every installed version of each tool, keyed by author/id
+	versions map[string][]*Tool
 }
 
 type Group struct {
-	ID    string     `yaml:"id"`
-	Tools []ToolInst `yaml:"tools"`
+	ID    string     `yaml:"id" json:"id"`
+	Tools []ToolInst `yaml:"tools" json:"tools"`
+	// Validate the group against each of these Puppet versions, as a list or a range
+	PuppetVersions []string `yaml:"puppet_versions" json:"puppet_versions,omitempty"`
+	// The group whose tools, and Puppet versions, this group inherits
+	Extends string `yaml:"extends" json:"extends,omitempty"`
+	// Groups whose tools this group also runs
+	Include []string `yaml:"include" json:"include,omitempty"`
+	// Tools run a stage at a time, once the tools of the earlier stages pass
+	Stages []Stage `yaml:"stages" json:"stages,omitempty"`
+	// Whether the group is one of the built-in ToolGroups rather than from validate.yml
+	Builtin bool `yaml:"-" json:"builtin"`
 }
 
 type ValidateYmlContent struct {
@@ -72,14 +79,14 @@ func (p *Prm) getGroupsFromFile(validateFile string) ([]Group, error) {
 	return contentStruct.Groups, nil
 }
 
+// A tool can run more than once in a group, but only with different args
 func checkDuplicateToolsInGroups(tools []ToolInst) error {
-	toolNames := make(map[string]bool)
-
-	for _, tool := range tools {
-		if toolNames[tool.Name] {
-			return fmt.Errorf("duplicate tool '%s' found. Validation groups cannot contain duplicate tools", tool.Name)
+	for i, tool := range tools {
+		for _, other := range tools[:i] {
+			if compareToolInst(tool, other) {
+				return fmt.Errorf("duplicate tool '%s' found. Validation groups cannot run the same tool with the same args twice", tool.Name)
+			}
 		}
-		toolNames[tool.Name] = true
 	}
 
 	return nil
@@ -93,24 +100,41 @@ func getSelectedGroup(groups []Group, selectedGroupID string) (Group, error) {
 		selectedGroupID = groups[0].ID
 	}
 
-	for _, group := range groups {
-		if group.ID == selectedGroupID {
-			err := checkDuplicateToolsInGroups(group.Tools)
-			if err != nil {
-				return Group{}, err
-			}
-			log.Info().Msgf("Found tool group: %v ", group.ID)
-			return group, nil
+	if group, ok := findGroup(groups, selectedGroupID); ok {
+		group, err := resolveGroup(groups, group)
+		if err != nil {
+			return Group{}, err
 		}
+		log.Info().Msgf("Found tool group: %v ", group.ID)
+		return group, nil
 	}
 
 	return Group{}, fmt.Errorf("specified tool group '%s' not found", selectedGroupID)
 }
 
+// Appends the built-in groups that a validate.yml group of the same ID does not replace
+func withBuiltinGroups(groups []Group) []Group {
+	defined := make(map[string]bool)
+	for _, group := range groups {
+		defined[group.ID] = true
+	}
+	for _, group := range BuiltinGroups() {
+		if !defined[group.ID] {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// GetValidationGroupFromFile selects a group from the codedir's validate.yml,
+// or one of the built-in groups, which can be selected without a validate.yml
 func (p *Prm) GetValidationGroupFromFile(selectedGroupID string) (Group, error) {
 	// check if validate.yml exits in the codeDir
 	validateFile, err := p.getValidateFilePath()
 	if err != nil {
+		if IsBuiltinGroup(selectedGroupID) {
+			return getSelectedGroup(BuiltinGroups(), selectedGroupID)
+		}
 		return Group{}, err
 	}
 
@@ -119,18 +143,38 @@ func (p *Prm) GetValidationGroupFromFile(selectedGroupID string) (Group, error)
 		return Group{}, err
 	}
 
-	return getSelectedGroup(groups, selectedGroupID)
+	return getSelectedGroup(withBuiltinGroups(groups), selectedGroupID)
 }
 
-// Check to see if the requested tool can be found installed.
-// If installed read the tool configuration and return
-func (p *Prm) IsToolAvailable(tool string) (*Tool, bool) {
+// ValidationGroups returns the groups of the codedir's validate.yml, if there
+// is one, followed by the built-in groups
+func (p *Prm) ValidationGroups() ([]Group, error) {
+	validateFile := filepath.Join(p.CodeDir, "validate.yml")
+	if exists, _ := p.AFS.Exists(validateFile); !exists {
+		return BuiltinGroups(), nil
+	}
+
+	groups, err := p.getGroupsFromFile(validateFile)
+	if err != nil {
+		return nil, err
+	}
+	return withBuiltinGroups(groups), nil
+}
 
-	if p.Cache[tool] != nil {
-		return p.Cache[tool], true
+// Check to see if the requested tool can be found installed.
+// The tool may be pinned with author/id@version or a constraint such as
+// author/id@~1.2, which is resolved against every installed version;
+// otherwise the newest installed version is returned
+func (p *Prm) IsToolAvailable(tool string) (*Tool, error) {
+	name, constraint, pinned := strings.Cut(tool, "@")
+	if !pinned {
+		if p.Cache[name] != nil {
+			return p.Cache[name], nil
+		}
+		return nil, fmt.Errorf("Tool %s not found in cache", name)
 	}
 
-	return nil, false
+	return p.resolveToolVersion(name, constraint)
 }
 
 // Check to see if the tool is ready to execute
@@ -139,11 +183,6 @@ func (p *Prm) IsToolReady(tool *Tool) bool {
 	return err == nil
 }
 
-// What version of Puppet is requested by the user
-func (*Prm) getPuppetVersion() PuppetVersion {
-	return PuppetVersion{}
-}
-
 func (p *Prm) readToolConfig(configFile string) Tool {
 	file, err := p.AFS.ReadFile(configFile)
 	if err != nil {
@@ -172,24 +211,7 @@ func (p *Prm) readToolConfig(configFile string) Tool {
 // not return any errors from parsing invalid templates, but returns them as
 // debug log events
 func (p *Prm) List(toolPath string, toolName string, onlyValidators bool) error {
-	log.Debug().Msgf("Searching %+v for tool configs", toolPath)
-	// Triple glob to match author/id/version/ToolConfigFileName
-	matches, _ := p.IOFS.Glob(toolPath + "/**/**/**/" + ToolConfigFileName)
-
-	var tmpls []ToolConfig
-	for _, file := range matches {
-		log.Debug().Msgf("Found: %+v", file)
-		i := p.readToolConfig(file)
-		if i.Cfg.Plugin != nil {
-			if onlyValidators && !i.Cfg.Common.CanValidate {
-				log.Debug().Msgf("Not a validator: %+v", file)
-				continue
-			}
-			i.Cfg.Path = filepath.Dir(file)
-			tmpls = append(tmpls, i.Cfg)
-		}
-	}
-
+	tmpls := p.readToolConfigs(toolPath, onlyValidators)
 	if len(tmpls) == 0 {
 		if onlyValidators {
 			return fmt.Errorf("no validators found in %+v", toolPath)
@@ -202,6 +224,7 @@ func (p *Prm) List(toolPath string, toolName string, onlyValidators bool) error
 		tmpls = p.FilterFiles(tmpls, func(f ToolConfig) bool { return f.Plugin.Id == toolName })
 	}
 
+	p.createVersionCache(tmpls)
 	tmpls = p.filterNewestVersions(tmpls)
 
 	// cache for use with the rest of the program
@@ -211,6 +234,28 @@ func (p *Prm) List(toolPath string, toolName string, onlyValidators bool) error
 	return nil
 }
 
+// Reads the config of every tool version installed under the tool path
+func (p *Prm) readToolConfigs(toolPath string, onlyValidators bool) []ToolConfig {
+	log.Debug().Msgf("Searching %+v for tool configs", toolPath)
+	// Triple glob to match author/id/version/ToolConfigFileName
+	matches, _ := p.IOFS.Glob(toolPath + "/**/**/**/" + ToolConfigFileName)
+
+	var tmpls []ToolConfig
+	for _, file := range matches {
+		log.Debug().Msgf("Found: %+v", file)
+		i := p.readToolConfig(file)
+		if i.Cfg.Plugin != nil {
+			if onlyValidators && !i.Cfg.Common.CanValidate {
+				log.Debug().Msgf("Not a validator: %+v", file)
+				continue
+			}
+			i.Cfg.Path = filepath.Dir(file)
+			tmpls = append(tmpls, i.Cfg)
+		}
+	}
+	return tmpls
+}
+
 func (p *Prm) filterNewestVersions(tt []ToolConfig) (ret []ToolConfig) {
 	for _, t := range tt {
 		id := t.Plugin.Id
@@ -0,0 +1,78 @@
+package prm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+// ParsePuppetVersions parses the Puppet versions to validate against, given
+// either as a comma separated list, e.g. "6.19.1,7.15.0", or as a range,
+// e.g. ">= 6.0.0 < 8.0.0", which selects every known version within it
+func ParsePuppetVersions(spec string) ([]*semver.Version, error) {
+	var versions []*semver.Version
+	for _, item := range strings.Split(spec, ",") {
+		version, err := semver.NewVersion(strings.TrimSpace(item))
+		if err != nil {
+			return resolvePuppetRange(spec)
+		}
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+func resolvePuppetRange(spec string) ([]*semver.Version, error) {
+	constraint, err := semver.NewConstraint(requirementBoundRegex.ReplaceAllString(spec, "$1, $2"))
+	if err != nil {
+		return nil, fmt.Errorf("'%s' is neither a list of Puppet versions nor a version range", spec)
+	}
+
+	var versions []*semver.Version
+	for _, raw := range KnownPuppetVersions {
+		version := semver.MustParse(raw)
+		if constraint.Check(version) {
+			versions = append(versions, version)
+		}
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no known Puppet version satisfies '%s'", spec)
+	}
+	return versions, nil
+}
+
+// ExpandPuppetMatrix schedules every tool against every Puppet version
+func ExpandPuppetMatrix(toolsInfo []ToolInfo, versions []*semver.Version) []ToolInfo {
+	if len(versions) == 0 {
+		return toolsInfo
+	}
+
+	var matrix []ToolInfo
+	for _, version := range versions {
+		for _, info := range toolsInfo {
+			info.PuppetVersion = version
+			matrix = append(matrix, info)
+		}
+	}
+	return matrix
+}
+
+// Whether the tools are validated against more than one Puppet version
+func isPuppetMatrix(toolsInfo []ToolInfo) bool {
+	versions := map[string]bool{}
+	for _, info := range toolsInfo {
+		if info.PuppetVersion != nil {
+			versions[info.PuppetVersion.String()] = true
+		}
+	}
+	return len(versions) > 1
+}
+
+// The config a tool runs with, taking any Puppet version it was scheduled against
+func (p *Prm) toolConfig(toolInfo ToolInfo) Config {
+	config := p.RunningConfig
+	if toolInfo.PuppetVersion != nil {
+		config.PuppetVersion = toolInfo.PuppetVersion
+	}
+	return config
+}
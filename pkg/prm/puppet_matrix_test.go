@@ -0,0 +1,113 @@
+package prm_test
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/puppetlabs/prm/internal/pkg/mock"
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePuppetVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "Single version",
+			spec: "7.15.0",
+			want: []string{"7.15.0"},
+		},
+		{
+			name: "List of versions",
+			spec: "6.19.1, 7.15.0",
+			want: []string{"6.19.1", "7.15.0"},
+		},
+		{
+			name: "Range selects the known versions within it",
+			spec: ">= 6.0.0 < 8.0.0",
+			want: []string{"6.19.1", prm.DefaultPuppetVer},
+		},
+		{
+			name: "Range without patch versions",
+			spec: ">=6 <8",
+			want: []string{"6.19.1", prm.DefaultPuppetVer},
+		},
+		{
+			name: "Range that selects a single known version",
+			spec: "7.x",
+			want: []string{prm.DefaultPuppetVer},
+		},
+		{
+			name:    "Range without a known version",
+			spec:    ">= 9.0.0",
+			wantErr: "no known Puppet version satisfies '>= 9.0.0'",
+		},
+		{
+			name:    "Invalid spec",
+			spec:    "latest",
+			wantErr: "'latest' is neither a list of Puppet versions nor a version range",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			versions, err := prm.ParsePuppetVersions(tt.spec)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			var got []string
+			for _, version := range versions {
+				got = append(got, version.String())
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPrm_Validate_PuppetMatrix(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afs := &afero.Afero{Fs: fs}
+	outputDir := "path/to/code/.prm-validate"
+	afs.MkdirAll(outputDir, 0755) //nolint:gosec,errcheck
+
+	backend := &mock.MockBackend{StatusIsAvailable: true, ToolAvalible: true, ValidateReturn: "PASS"}
+	p := &prm.Prm{
+		AFS:           afs,
+		IOFS:          &afero.IOFS{Fs: fs},
+		RunningConfig: prm.Config{PuppetVersion: semver.MustParse(prm.DefaultPuppetVer)},
+		CodeDir:       "path/to/code",
+		Backend:       backend,
+	}
+
+	versions := []*semver.Version{semver.MustParse("6.19.1"), semver.MustParse("7.15.0")}
+	tools := prm.ExpandPuppetMatrix([]prm.ToolInfo{CreateToolInfo("lint", "puppetlabs", "0.1.0", nil)}, versions)
+	assert.Len(t, tools, 2)
+
+	settings := prm.OutputSettings{ResultsView: "file", OutputDir: outputDir, ReportFormat: prm.ReportFormatJson}
+	assert.NoError(t, p.Validate(context.Background(), tools, 1, settings))
+	assert.Equal(t, []string{"6.19.1", "7.15.0"}, backend.ValidatePuppetVersions)
+	assert.Equal(t, prm.DefaultPuppetVer, p.RunningConfig.PuppetVersion.String())
+
+	content, err := afs.ReadFile(filepath.Join(outputDir, "report.json"))
+	assert.NoError(t, err)
+	var report struct {
+		Tools []struct {
+			Name          string `json:"name"`
+			PuppetVersion string `json:"puppetVersion"`
+		} `json:"tools"`
+	}
+	assert.NoError(t, json.Unmarshal(content, &report))
+	assert.Len(t, report.Tools, 2)
+	assert.Equal(t, "lint_puppet-6.19.1", report.Tools[0].Name)
+	assert.Equal(t, "6.19.1", report.Tools[0].PuppetVersion)
+	assert.Equal(t, "lint_puppet-7.15.0", report.Tools[1].Name)
+}
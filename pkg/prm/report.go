@@ -0,0 +1,361 @@
+package prm
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	ReportFormatJunit = "junit"
+	ReportFormatJson  = "json"
+	ReportFormatSarif = "sarif"
+
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+var (
+	ReportFormats = []string{ReportFormatJunit, ReportFormatJson, ReportFormatSarif}
+
+	reportFileNames = map[string]string{
+		ReportFormatJunit: "report.xml",
+		ReportFormatJson:  "report.json",
+		ReportFormatSarif: "report.sarif",
+	}
+)
+
+// A single tool's validation result, as written to a report
+type reportEntry struct {
+	Name          string    `json:"name"`
+	PuppetVersion string    `json:"puppetVersion,omitempty"`
+	Result        string    `json:"result"`
+	ExitCode      int64     `json:"exitCode"` // of the tool's process, or -1 if it did not run to completion
+	Duration      float64   `json:"duration"` // seconds
+	Stdout        string    `json:"stdout"`
+	Stderr        string    `json:"stderr"`
+	Error         string    `json:"error,omitempty"` // why the tool could not be run
+	Findings      []Finding `json:"findings,omitempty"`
+	Skipped       string    `json:"skipped,omitempty"` // why the tool was not run
+}
+
+type jsonReport struct {
+	Tools []reportEntry `json:"tools"`
+}
+
+type junitTestSuites struct {
+	XMLName    xml.Name         `xml:"testsuites"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Errors     int              `xml:"errors,attr"`
+	Skipped    int              `xml:"skipped,attr"`
+	Time       string           `xml:"time,attr"`
+	TestSuites []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	TestCases  []junitTestCase `xml:"testcase"`
+	SystemOut  string          `xml:"system-out"`
+	SystemErr  string          `xml:"system-err"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations"`
+	Results     []sarifResult     `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name string `json:"name"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful bool                   `json:"executionSuccessful"`
+	ExitCode            int64                  `json:"exitCode"`
+	Properties          map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId,omitempty"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+func IsValidReportFormat(format string) bool {
+	_, ok := reportFileNames[format]
+	return ok
+}
+
+func (p *Prm) writeReport(tasks []*Task[ValidationOutput], settings OutputSettings) error {
+	if settings.ReportFormat == "" {
+		return nil
+	}
+
+	fileName, ok := reportFileNames[settings.ReportFormat]
+	if !ok {
+		return fmt.Errorf("invalid --report-format flag specified")
+	}
+
+	content, err := createReport(createReportEntries(tasks), settings.ReportFormat)
+	if err != nil {
+		return err
+	}
+
+	err = p.checkAndCreateDir(settings.OutputDir)
+	if err != nil {
+		return err
+	}
+
+	reportPath := filepath.Join(settings.OutputDir, fileName)
+	err = p.AFS.WriteFile(reportPath, content, 0644)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf("Wrote %s report to %s", settings.ReportFormat, reportPath)
+	return nil
+}
+
+func createReportEntries(tasks []*Task[ValidationOutput]) []reportEntry {
+	entries := make([]reportEntry, 0, len(tasks))
+	for _, task := range tasks {
+		output := task.Output
+		entry := reportEntry{
+			Name:          task.Name,
+			PuppetVersion: output.puppetVersion,
+			Result:        resultName(output),
+			ExitCode:      int64(output.toolExitCode),
+			Duration:      output.duration.Seconds(),
+			Stdout:        cleanOutput(output.stdout),
+			Stderr:        cleanOutput(output.stderr),
+			Findings:      output.findings,
+			Skipped:       output.skipped,
+		}
+		if entry.Result == "error" && output.err != nil {
+			entry.Error = cleanOutput(output.err.Error())
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func resultName(output ValidationOutput) string {
+	if output.cancelled {
+		return "cancelled"
+	}
+	if output.skipped != "" {
+		return "skipped"
+	}
+	switch output.exitCode {
+	case VALIDATION_PASS:
+		return "passed"
+	case VALIDATION_FAILED:
+		// Failures suppressed by the baseline pass
+		if output.err == nil {
+			return "passed"
+		}
+		return "failed"
+	default:
+		return "error"
+	}
+}
+
+func createReport(entries []reportEntry, format string) ([]byte, error) {
+	switch format {
+	case ReportFormatJunit:
+		content, err := xml.MarshalIndent(createJunitReport(entries), "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(xml.Header), content...), nil
+	case ReportFormatJson:
+		return json.MarshalIndent(jsonReport{Tools: entries}, "", "  ")
+	case ReportFormatSarif:
+		return json.MarshalIndent(createSarifReport(entries), "", "  ")
+	}
+	return nil, fmt.Errorf("unknown report format '%s'", format)
+}
+
+func createJunitReport(entries []reportEntry) junitTestSuites {
+	report := junitTestSuites{}
+	totalTime := 0.0
+	for _, entry := range entries {
+		suite := junitTestSuite{
+			Name:       entry.Name,
+			Tests:      1,
+			Time:       formatSeconds(entry.Duration),
+			Properties: []junitProperty{{Name: "exitCode", Value: fmt.Sprint(entry.ExitCode)}, {Name: "puppetVersion", Value: entry.PuppetVersion}},
+			SystemOut:  entry.Stdout,
+			SystemErr:  entry.Stderr,
+		}
+		testCase := junitTestCase{Name: entry.Name, ClassName: entry.Name, Time: suite.Time}
+		switch entry.Result {
+		case "failed":
+			suite.Failures = 1
+			testCase.Failure = &junitMessage{Message: fmt.Sprintf("%s failed validation", entry.Name), Text: entry.Stderr}
+		case "error":
+			suite.Errors = 1
+			text := entry.Error
+			if entry.Stderr != "" {
+				text = entry.Stderr
+			}
+			testCase.Error = &junitMessage{Message: fmt.Sprintf("%s encountered an error", entry.Name), Text: text}
+		case "cancelled":
+			suite.Skipped = 1
+			testCase.Skipped = &junitMessage{Message: fmt.Sprintf("%s was cancelled", entry.Name)}
+		case "skipped":
+			suite.Skipped = 1
+			testCase.Skipped = &junitMessage{Message: fmt.Sprintf("%s was skipped because %s", entry.Name, entry.Skipped)}
+		}
+		suite.TestCases = []junitTestCase{testCase}
+
+		// Each finding is reported as a test case of its own, which
+		// fails only when the tool failed validation because of it
+		for _, finding := range entry.Findings {
+			suite.Tests++
+			testCase := junitTestCase{
+				Name:      formatFinding(finding),
+				ClassName: finding.File,
+				Time:      formatSeconds(0),
+			}
+			if entry.Result == "failed" {
+				suite.Failures++
+				testCase.Failure = &junitMessage{Message: finding.Message, Text: formatFinding(finding)}
+			}
+			suite.TestCases = append(suite.TestCases, testCase)
+		}
+
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Skipped += suite.Skipped
+		totalTime += entry.Duration
+		report.TestSuites = append(report.TestSuites, suite)
+	}
+	report.Time = formatSeconds(totalTime)
+	return report
+}
+
+func createSarifReport(entries []reportEntry) sarifLog {
+	report := sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{}}
+	for _, entry := range entries {
+		run := sarifRun{
+			Tool: sarifTool{Driver: sarifDriver{Name: entry.Name}},
+			Invocations: []sarifInvocation{
+				{
+					ExecutionSuccessful: entry.Result == "passed" || entry.Result == "failed",
+					ExitCode:            entry.ExitCode,
+					// SARIF has no place of its own for what a tool wrote, so it is kept
+					// with the invocation as in the other report formats
+					Properties: map[string]interface{}{
+						"duration":      entry.Duration,
+						"puppetVersion": entry.PuppetVersion,
+						"stdout":        entry.Stdout,
+						"stderr":        entry.Stderr,
+					},
+				},
+			},
+			Results: []sarifResult{},
+		}
+		for _, finding := range entry.Findings {
+			run.Results = append(run.Results, createSarifResult(finding))
+		}
+		if (entry.Result == "failed" || entry.Result == "error") && len(entry.Findings) == 0 {
+			text := entry.Stderr
+			if text == "" {
+				text = entry.Error
+			}
+			if text == "" {
+				text = entry.Stdout
+			}
+			run.Results = append(run.Results, sarifResult{Level: "error", Message: sarifMessage{Text: text}})
+		}
+		report.Runs = append(report.Runs, run)
+	}
+	return report
+}
+
+func createSarifResult(finding Finding) sarifResult {
+	level := "warning"
+	switch finding.Severity {
+	case SeverityError:
+		level = "error"
+	case SeverityInfo:
+		level = "note"
+	}
+
+	result := sarifResult{RuleID: finding.Rule, Level: level, Message: sarifMessage{Text: finding.Message}}
+	if finding.File != "" {
+		location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: finding.File}}}
+		if finding.Line > 0 {
+			location.PhysicalLocation.Region = &sarifRegion{StartLine: finding.Line}
+		}
+		result.Locations = []sarifLocation{location}
+	}
+	return result
+}
+
+func formatSeconds(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
@@ -0,0 +1,297 @@
+package prm_test
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"path/filepath"
+	"testing"
+
+	"github.com/puppetlabs/prm/internal/pkg/mock"
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrm_Validate_Report(t *testing.T) {
+	outputDir := "path/to/code/.prm-validate"
+	tests := []struct {
+		name           string
+		validateReturn string
+		validateStderr string
+		validateStdout string
+		outputMode     *prm.OutputModes
+		reportFormat   string
+		reportFile     string
+		check          func(t *testing.T, content []byte)
+	}{
+		{
+			name:           "JUnit report of a failing tool",
+			validateReturn: "FAIL",
+			validateStderr: "1 offence found",
+			reportFormat:   prm.ReportFormatJunit,
+			reportFile:     "report.xml",
+			check: func(t *testing.T, content []byte) {
+				var report struct {
+					Tests      int `xml:"tests,attr"`
+					Failures   int `xml:"failures,attr"`
+					TestSuites []struct {
+						Name      string `xml:"name,attr"`
+						SystemErr string `xml:"system-err"`
+						TestCases []struct {
+							Failure *struct {
+								Text string `xml:",chardata"`
+							} `xml:"failure"`
+						} `xml:"testcase"`
+					} `xml:"testsuite"`
+				}
+				assert.NoError(t, xml.Unmarshal(content, &report))
+				assert.Equal(t, 2, report.Tests)
+				assert.Equal(t, 2, report.Failures)
+				assert.Equal(t, "my-tool0", report.TestSuites[0].Name)
+				assert.Equal(t, "1 offence found", report.TestSuites[0].SystemErr)
+				assert.Equal(t, "1 offence found", report.TestSuites[0].TestCases[0].Failure.Text)
+			},
+		},
+		{
+			name:           "JUnit report of the findings of a passing tool",
+			validateReturn: "PASS",
+			validateStdout: `[{"path": "manifests/init.pp", "line": 3, "kind": "warning", "check": "140chars", "message": "line has more than 140 characters"}]`,
+			outputMode:     &prm.OutputModes{Json: "--json"},
+			reportFormat:   prm.ReportFormatJunit,
+			reportFile:     "report.xml",
+			check: func(t *testing.T, content []byte) {
+				var report struct {
+					Failures   int `xml:"failures,attr"`
+					TestSuites []struct {
+						Failures  int `xml:"failures,attr"`
+						TestCases []struct {
+							Name    string    `xml:"name,attr"`
+							Failure *struct{} `xml:"failure"`
+						} `xml:"testcase"`
+					} `xml:"testsuite"`
+				}
+				assert.NoError(t, xml.Unmarshal(content, &report))
+				assert.Equal(t, 0, report.Failures)
+				assert.Equal(t, 0, report.TestSuites[0].Failures)
+				// The tool's own test case and that of its finding
+				assert.Len(t, report.TestSuites[0].TestCases, 2)
+				for _, testCase := range report.TestSuites[0].TestCases {
+					assert.Nil(t, testCase.Failure)
+				}
+			},
+		},
+		{
+			name:           "JSON report of a passing tool",
+			validateReturn: "PASS",
+			validateStderr: "1 deprecation warning",
+			reportFormat:   prm.ReportFormatJson,
+			reportFile:     "report.json",
+			check: func(t *testing.T, content []byte) {
+				var report struct {
+					Tools []struct {
+						Name     string `json:"name"`
+						Result   string `json:"result"`
+						ExitCode int    `json:"exitCode"`
+						Stderr   string `json:"stderr"`
+					} `json:"tools"`
+				}
+				assert.NoError(t, json.Unmarshal(content, &report))
+				assert.Len(t, report.Tools, 2)
+				assert.Equal(t, "my-tool1", report.Tools[1].Name)
+				assert.Equal(t, "passed", report.Tools[1].Result)
+				assert.Equal(t, 0, report.Tools[1].ExitCode)
+				assert.Equal(t, "1 deprecation warning", report.Tools[1].Stderr)
+			},
+		},
+		{
+			name:           "SARIF report of an erroring tool",
+			validateReturn: "ERROR",
+			reportFormat:   prm.ReportFormatSarif,
+			reportFile:     "report.sarif",
+			check: func(t *testing.T, content []byte) {
+				var report struct {
+					Version string `json:"version"`
+					Runs    []struct {
+						Tool struct {
+							Driver struct {
+								Name string `json:"name"`
+							} `json:"driver"`
+						} `json:"tool"`
+						Invocations []struct {
+							ExecutionSuccessful bool `json:"executionSuccessful"`
+							ExitCode            int  `json:"exitCode"`
+						} `json:"invocations"`
+						Results []struct {
+							Level   string `json:"level"`
+							Message struct {
+								Text string `json:"text"`
+							} `json:"message"`
+						} `json:"results"`
+					} `json:"runs"`
+				}
+				assert.NoError(t, json.Unmarshal(content, &report))
+				assert.Equal(t, "2.1.0", report.Version)
+				assert.Len(t, report.Runs, 2)
+				assert.Equal(t, "my-tool0", report.Runs[0].Tool.Driver.Name)
+				assert.False(t, report.Runs[0].Invocations[0].ExecutionSuccessful)
+				// The tool did not exit, so has no exit code of its own
+				assert.Equal(t, prm.NoExitCode, report.Runs[0].Invocations[0].ExitCode)
+				assert.Equal(t, "DOCKER ERROR", report.Runs[0].Results[0].Message.Text)
+			},
+		},
+		{
+			name:           "SARIF report of the output of a passing tool",
+			validateReturn: "PASS",
+			validateStdout: "1 file checked",
+			validateStderr: "1 deprecation warning",
+			reportFormat:   prm.ReportFormatSarif,
+			reportFile:     "report.sarif",
+			check: func(t *testing.T, content []byte) {
+				var report struct {
+					Runs []struct {
+						Invocations []struct {
+							ExecutionSuccessful bool `json:"executionSuccessful"`
+							Properties          struct {
+								Stdout string `json:"stdout"`
+								Stderr string `json:"stderr"`
+							} `json:"properties"`
+						} `json:"invocations"`
+						Results []interface{} `json:"results"`
+					} `json:"runs"`
+				}
+				assert.NoError(t, json.Unmarshal(content, &report))
+				assert.Len(t, report.Runs, 2)
+				invocation := report.Runs[1].Invocations[0]
+				assert.True(t, invocation.ExecutionSuccessful)
+				assert.Equal(t, "1 file checked", invocation.Properties.Stdout)
+				assert.Equal(t, "1 deprecation warning", invocation.Properties.Stderr)
+				assert.Empty(t, report.Runs[1].Results)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			afs := &afero.Afero{Fs: fs}
+			p := &prm.Prm{
+				AFS:     afs,
+				IOFS:    &afero.IOFS{Fs: fs},
+				CodeDir: "path/to/code",
+				Backend: &mock.MockBackend{
+					StatusIsAvailable: true,
+					ToolAvalible:      true,
+					ValidateReturn:    tt.validateReturn,
+					ValidateStderr:    tt.validateStderr,
+					ValidateStdout:    tt.validateStdout,
+				},
+			}
+			tools := []prm.ToolInfo{
+				CreateToolInfo("my-tool0", "puppetlabs", "0.1.0", nil),
+				CreateToolInfo("my-tool1", "puppetlabs", "0.1.0", nil),
+			}
+			for i := range tools {
+				tools[i].Tool.Cfg.Common.OutputMode = tt.outputMode
+			}
+
+			_ = p.Validate(context.Background(), tools, 1, prm.OutputSettings{ResultsView: "terminal", OutputDir: outputDir, ReportFormat: tt.reportFormat})
+
+			content, err := afs.ReadFile(filepath.Join(outputDir, tt.reportFile))
+			assert.NoError(t, err)
+			tt.check(t, content)
+		})
+	}
+}
+
+func TestPrm_Validate_FailFast(t *testing.T) {
+	outputDir := "path/to/code/.prm-validate"
+	tests := []struct {
+		name           string
+		validateReturn string
+		failFast       bool
+		interrupted    bool
+		wantCalls      int
+		wantResults    []string
+		wantErrMsg     string
+	}{
+		{
+			name:           "The tools after a failure are cancelled",
+			validateReturn: "FAIL",
+			failFast:       true,
+			wantCalls:      1,
+			wantResults:    []string{"failed", "cancelled", "cancelled"},
+			wantErrMsg:     "Validation returned 1 error",
+		},
+		{
+			name:           "The tools after an error are cancelled",
+			validateReturn: "ERROR",
+			failFast:       true,
+			wantCalls:      1,
+			wantResults:    []string{"error", "cancelled", "cancelled"},
+			wantErrMsg:     "Validation returned 1 error",
+		},
+		{
+			name:           "Passing tools all run",
+			validateReturn: "PASS",
+			failFast:       true,
+			wantCalls:      3,
+			wantResults:    []string{"passed", "passed", "passed"},
+		},
+		{
+			name:           "Interrupting validation cancels every tool",
+			validateReturn: "PASS",
+			interrupted:    true,
+			wantCalls:      0,
+			wantResults:    []string{"cancelled", "cancelled", "cancelled"},
+			wantErrMsg:     prm.ErrValidationCancelled.Error(),
+		},
+		{
+			name:           "Without fail-fast every tool runs",
+			validateReturn: "FAIL",
+			wantCalls:      3,
+			wantResults:    []string{"failed", "failed", "failed"},
+			wantErrMsg:     "Validation returned 3 errors",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			afs := &afero.Afero{Fs: fs}
+			backend := &mock.MockBackend{StatusIsAvailable: true, ToolAvalible: true, ValidateReturn: tt.validateReturn}
+			p := &prm.Prm{AFS: afs, IOFS: &afero.IOFS{Fs: fs}, CodeDir: "path/to/code", Backend: backend}
+			tools := []prm.ToolInfo{
+				CreateToolInfo("my-tool0", "puppetlabs", "0.1.0", nil),
+				CreateToolInfo("my-tool1", "puppetlabs", "0.1.0", nil),
+				CreateToolInfo("my-tool2", "puppetlabs", "0.1.0", nil),
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.interrupted {
+				cancel()
+			}
+
+			err := p.Validate(ctx, tools, 1, prm.OutputSettings{ResultsView: "terminal", OutputDir: outputDir, ReportFormat: prm.ReportFormatJson, FailFast: tt.failFast})
+			if tt.wantErrMsg != "" {
+				assert.EqualError(t, err, tt.wantErrMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCalls, backend.ValidateCalls)
+
+			content, err := afs.ReadFile(filepath.Join(outputDir, "report.json"))
+			assert.NoError(t, err)
+			var report struct {
+				Tools []struct {
+					Result string `json:"result"`
+				} `json:"tools"`
+			}
+			assert.NoError(t, json.Unmarshal(content, &report))
+			var results []string
+			for _, tool := range report.Tools {
+				results = append(results, tool.Result)
+			}
+			assert.Equal(t, tt.wantResults, results)
+		})
+	}
+}
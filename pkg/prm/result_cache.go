@@ -0,0 +1,192 @@
+package prm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// The directory of the cache dir that validation results are cached in
+const resultCacheDirName = "validate"
+
+// resultCache stores the output of each tool against the content of the
+// codedir, so tools are not run again when nothing they depend on changed
+type resultCache struct {
+	prm         *Prm
+	dir         string
+	contentHash string
+}
+
+type cachedResult struct {
+	ExitCode     ValidateExitCode `json:"exitCode"`
+	ToolExitCode int              `json:"toolExitCode"`
+	Stdout       string           `json:"stdout"`
+	Stderr       string           `json:"stderr,omitempty"`
+	Error        *string          `json:"error,omitempty"`
+	Findings     []Finding        `json:"findings,omitempty"`
+	Duration     time.Duration    `json:"duration"`
+}
+
+// Creates a cache of validation results under the cache dir, hashing the
+// content of the codedir once for every tool to share
+func (p *Prm) newResultCache() (*resultCache, error) {
+	if p.CacheDir == "" {
+		return nil, errors.New("no cache dir set")
+	}
+
+	contentHash, err := p.hashCodeDir()
+	if err != nil {
+		return nil, err
+	}
+	return &resultCache{prm: p, dir: filepath.Join(p.CacheDir, resultCacheDirName), contentHash: contentHash}, nil
+}
+
+// Hashes the path and content of every file in the codedir that is not ignored
+func (p *Prm) hashCodeDir() (string, error) {
+	files, err := p.codeDirFiles()
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	for _, file := range files {
+		content, err := p.AFS.Open(filepath.Join(p.CodeDir, file))
+		if err != nil {
+			return "", err
+		}
+		fileHash := sha256.New()
+		_, err = io.Copy(fileHash, content)
+		content.Close() //nolint:errcheck,gosec
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(hash, "%s\x00%x\n", file, fileHash.Sum(nil))
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Lists the files in the codedir, relative to it and sorted, that are not ignored
+// and are not PRM's own state, which changes without the content changing
+func (p *Prm) codeDirFiles() ([]string, error) {
+	rules := p.readIgnoreRules()
+	cacheDir, _ := filepath.Abs(p.CacheDir)
+
+	var files []string
+	err := p.AFS.Walk(p.CodeDir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(p.CodeDir, file)
+		if err != nil || relPath == "." {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if info.IsDir() {
+			if absPath, _ := filepath.Abs(file); (p.CacheDir != "" && absPath == cacheDir) || isIgnored(rules, relPath, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode().IsRegular() && !isIgnored(rules, relPath, false) && !isPrmStateFile(relPath) {
+			files = append(files, relPath)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// The key a tool's result is cached under
+func (c *resultCache) key(tool ToolInfo, config Config) (string, error) {
+	toolConfig, err := json.Marshal(tool.Tool.Cfg)
+	if err != nil {
+		return "", err
+	}
+
+	imageID := ""
+	if inspector, ok := c.prm.Backend.(ToolImageInspectorI); ok {
+		_, imageID, err = inspector.ToolImage(tool.Tool, config)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	puppetVersion := ""
+	if config.PuppetVersion != nil {
+		puppetVersion = config.PuppetVersion.String()
+	}
+
+	var env []string
+	for key, val := range tool.Env {
+		env = append(env, key+"="+val)
+	}
+	sort.Strings(env)
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "tool:%s\nimage:%s\nargs:%s\nenv:%s\npuppet:%s\ncontent:%s\n", toolConfig, imageID, strings.Join(tool.Args, "\x00"), strings.Join(env, "\x00"), puppetVersion, c.contentHash)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func (c *resultCache) get(key string) (ValidationOutput, bool) {
+	content, err := c.prm.AFS.ReadFile(filepath.Join(c.dir, key+".json"))
+	if err != nil {
+		return ValidationOutput{}, false
+	}
+
+	var result cachedResult
+	if err := json.Unmarshal(content, &result); err != nil {
+		log.Debug().Msgf("Ignoring unreadable cached result %s: %s", key, err)
+		return ValidationOutput{}, false
+	}
+
+	output := ValidationOutput{
+		exitCode:     result.ExitCode,
+		toolExitCode: result.ToolExitCode,
+		stdout:       result.Stdout,
+		stderr:       result.Stderr,
+		findings:     result.Findings,
+		duration:     result.Duration,
+		cached:       true,
+	}
+	if result.Error != nil {
+		output.err = errors.New(*result.Error)
+	}
+	return output, true
+}
+
+func (c *resultCache) put(key string, output ValidationOutput) {
+	result := cachedResult{
+		ExitCode:     output.exitCode,
+		ToolExitCode: output.toolExitCode,
+		Stdout:       output.stdout,
+		Stderr:       output.stderr,
+		Findings:     output.findings,
+		Duration:     output.duration,
+	}
+	if output.err != nil {
+		errText := output.err.Error()
+		result.Error = &errText
+	}
+
+	content, err := json.Marshal(result)
+	if err == nil {
+		err = c.prm.AFS.MkdirAll(c.dir, 0750)
+	}
+	if err == nil {
+		err = c.prm.AFS.WriteFile(filepath.Join(c.dir, key+".json"), content, 0600)
+	}
+	if err != nil {
+		log.Warn().Msgf("Unable to cache the validation result: %s", err)
+	}
+}
@@ -0,0 +1,137 @@
+package prm_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/puppetlabs/prm/internal/pkg/mock"
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrm_Validate_ResultCache(t *testing.T) {
+	codeDir := "path/to/code"
+	tests := []struct {
+		name       string
+		change     func(afs *afero.Afero)
+		tool       prm.ToolInfo
+		noCache    bool
+		wantCached bool
+	}{
+		{
+			name:       "Unchanged content is cached",
+			change:     func(afs *afero.Afero) {},
+			wantCached: true,
+		},
+		{
+			name: "Changed content is validated again",
+			change: func(afs *afero.Afero) {
+				afs.WriteFile(filepath.Join(codeDir, "manifests/init.pp"), []byte("class motd {}\n"), 0644) //nolint:errcheck
+			},
+		},
+		{
+			name: "New content is validated again",
+			change: func(afs *afero.Afero) {
+				afs.WriteFile(filepath.Join(codeDir, "manifests/config.pp"), []byte("class motd::config {}"), 0644) //nolint:errcheck
+			},
+		},
+		{
+			name: "Content ignored by .gitignore is cached",
+			change: func(afs *afero.Afero) {
+				afs.WriteFile(filepath.Join(codeDir, "pkg/motd.tar.gz"), []byte("changed"), 0644)    //nolint:errcheck
+				afs.WriteFile(filepath.Join(codeDir, "manifests/init.pp.swp"), []byte("swap"), 0644) //nolint:errcheck
+			},
+			wantCached: true,
+		},
+		{
+			name: "Content ignored by .pdkignore is cached",
+			change: func(afs *afero.Afero) {
+				afs.WriteFile(filepath.Join(codeDir, "spec/fixtures/modules/stdlib/init.pp"), []byte("changed"), 0644) //nolint:errcheck
+			},
+			wantCached: true,
+		},
+		{
+			name: "Content ignored by a nested .gitignore is cached",
+			change: func(afs *afero.Afero) {
+				afs.WriteFile(filepath.Join(codeDir, "manifests/init.pp.bak"), []byte("backup"), 0644) //nolint:errcheck
+			},
+			wantCached: true,
+		},
+		{
+			name: "Content outside of the directory of a nested .gitignore is validated again",
+			change: func(afs *afero.Afero) {
+				afs.WriteFile(filepath.Join(codeDir, "init.pp.bak"), []byte("backup"), 0644) //nolint:errcheck
+			},
+		},
+		{
+			name: "The baseline and lock file are cached",
+			change: func(afs *afero.Afero) {
+				afs.WriteFile(filepath.Join(codeDir, prm.BaselineFileName), []byte(`{"tools": {}}`), 0644) //nolint:errcheck
+				afs.WriteFile(filepath.Join(codeDir, prm.LockFileName), []byte("tools: []"), 0644)         //nolint:errcheck
+			},
+			wantCached: true,
+		},
+		{
+			name: "Validation logs are cached",
+			change: func(afs *afero.Afero) {
+				afs.WriteFile(filepath.Join(codeDir, ".prm-validate/lint.log"), []byte("log"), 0644) //nolint:errcheck
+			},
+			wantCached: true,
+		},
+		{
+			name:   "Different args are validated again",
+			change: func(afs *afero.Afero) {},
+			tool:   CreateToolInfo("lint", "puppetlabs", "0.1.0", []string{"--fix"}),
+		},
+		{
+			name:    "Cache can be bypassed",
+			change:  func(afs *afero.Afero) {},
+			noCache: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			afs := &afero.Afero{Fs: fs}
+			afs.MkdirAll(filepath.Join(codeDir, "manifests"), 0750)                                       //nolint:errcheck
+			afs.WriteFile(filepath.Join(codeDir, "manifests/init.pp"), []byte("class motd {\n}\n"), 0644) //nolint:errcheck
+			afs.WriteFile(filepath.Join(codeDir, ".gitignore"), []byte("/pkg/\n*.swp\n"), 0644)           //nolint:errcheck
+			afs.WriteFile(filepath.Join(codeDir, ".pdkignore"), []byte("/spec/fixtures/\n"), 0644)        //nolint:errcheck
+			afs.WriteFile(filepath.Join(codeDir, "manifests/.gitignore"), []byte("*.bak\n"), 0644)        //nolint:errcheck
+
+			backend := &mock.MockBackend{StatusIsAvailable: true, ToolAvalible: true, ValidateReturn: "FAIL"}
+			p := &prm.Prm{
+				AFS:           afs,
+				IOFS:          &afero.IOFS{Fs: fs},
+				RunningConfig: prm.Config{PuppetVersion: semver.MustParse(prm.DefaultPuppetVer)},
+				CodeDir:       codeDir,
+				CacheDir:      "path/to/cache",
+				Backend:       backend,
+			}
+			settings := prm.OutputSettings{ResultsView: "terminal", UseResultCache: true}
+			tool := CreateToolInfo("lint", "puppetlabs", "0.1.0", nil)
+
+			err := p.Validate(context.Background(), []prm.ToolInfo{tool}, 1, settings)
+			assert.EqualError(t, err, "Validation returned 1 error")
+			assert.Equal(t, 1, backend.ValidateCalls)
+
+			tt.change(afs)
+			if tt.tool.Tool != nil {
+				tool = tt.tool
+			}
+			settings.UseResultCache = !tt.noCache
+			err = p.Validate(context.Background(), []prm.ToolInfo{tool}, 1, settings)
+			// Cached failures still fail validation
+			assert.EqualError(t, err, "Validation returned 1 error")
+
+			wantCalls := 2
+			if tt.wantCached {
+				wantCalls = 1
+			}
+			assert.Equal(t, wantCalls, backend.ValidateCalls)
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package prm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Stage of a validation group. The tools of a stage run in parallel,
+// once the tools of the stages before it have passed.
+type Stage struct {
+	Name  string     `yaml:"name" json:"name,omitempty"`
+	Tools []ToolInst `yaml:"tools" json:"tools"`
+}
+
+// Returns the tools of the group's stages, each with the stage it runs in
+func (g Group) stageTools() []ToolInst {
+	var tools []ToolInst
+	for i, stage := range g.Stages {
+		for _, tool := range stage.Tools {
+			tool.Stage = i + 1
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
+
+// Moves the stages of the tools after the given stage, so they run once it
+// has passed, returning the moved tools and the last stage they run in
+func shiftStages(tools []ToolInst, after int) ([]ToolInst, int) {
+	shifted := make([]ToolInst, len(tools))
+	last := after
+	for i, tool := range tools {
+		if tool.Stage > 0 {
+			tool.Stage += after
+			if tool.Stage > last {
+				last = tool.Stage
+			}
+		}
+		shifted[i] = tool
+	}
+	return shifted, last
+}
+
+// Whether a tool, given by its stage and needs, must wait for another.
+// A need names either the other tool or the ID of its run.
+func dependsOn(stage int, needs []string, otherName string, otherID string, otherStage int) bool {
+	if otherStage > 0 && stage > otherStage {
+		return true
+	}
+	otherName, _, _ = strings.Cut(otherName, "@")
+	for _, need := range needs {
+		if need == otherID {
+			return true
+		}
+		need, _, _ = strings.Cut(need, "@")
+		if need == otherName {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks the tools of a group only need tools of the group,
+// and that they do not need each other in a cycle
+func checkToolDependencies(group Group) error {
+	for _, tool := range group.Tools {
+		for _, need := range tool.Needs {
+			found := false
+			for _, other := range group.Tools {
+				if other.ID != tool.ID && dependsOn(0, []string{need}, other.Name, other.ID, 0) {
+					found = true
+				}
+			}
+			if !found {
+				return fmt.Errorf("tool '%s' needs '%s', which is not in the tool group '%s'", tool.Name, need, group.ID)
+			}
+		}
+	}
+
+	graph := make([][]int, len(group.Tools))
+	for i, tool := range group.Tools {
+		for j, other := range group.Tools {
+			if i != j && dependsOn(tool.Stage, tool.Needs, other.Name, other.ID, other.Stage) {
+				graph[i] = append(graph[i], j)
+			}
+		}
+	}
+	if cycle := findCycle(graph); cycle != nil {
+		var names []string
+		for _, i := range cycle {
+			names = append(names, group.Tools[i].Name)
+		}
+		return fmt.Errorf("the needs of the tools in the tool group '%s' form a cycle: %s", group.ID, strings.Join(names, " -> "))
+	}
+	return nil
+}
+
+// Returns the nodes of a cycle in the graph, starting and ending with the same node, if there is one
+func findCycle(graph [][]int) []int {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(graph))
+	var path []int
+
+	var visit func(node int) []int
+	visit = func(node int) []int {
+		state[node] = visiting
+		path = append(path, node)
+		for _, next := range graph[node] {
+			switch state[next] {
+			case visiting:
+				for i, n := range path {
+					if n == next {
+						return append(append([]int{}, path[i:]...), next)
+					}
+				}
+			case unvisited:
+				if cycle := visit(next); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[node] = visited
+		return nil
+	}
+
+	for node := range graph {
+		if state[node] == unvisited {
+			if cycle := visit(node); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// Makes each task need the tasks of the tools its tool needs,
+// or that run in an earlier stage, for the same Puppet version
+func linkTaskNeeds(tasks []*Task[ValidationOutput], toolsInfo []ToolInfo) {
+	for i, info := range toolsInfo {
+		for j, other := range toolsInfo {
+			if i != j && toolDependsOn(info, other) {
+				tasks[i].Needs = append(tasks[i].Needs, tasks[j])
+			}
+		}
+	}
+}
+
+// Whether a scheduled tool must wait for another before it runs
+func toolDependsOn(info ToolInfo, other ToolInfo) bool {
+	if !samePuppetVersion(info, other) {
+		return false
+	}
+	otherName := other.Tool.Cfg.Plugin.Author + "/" + other.Tool.Cfg.Plugin.Id
+	return dependsOn(info.Stage, info.Needs, otherName, other.invocationID(), other.Stage)
+}
+
+// Returns whether each tool waits for each other tool, directly or
+// through the tools it waits for, so tools that wait for neither
+// can run at the same time
+func toolWaits(toolsInfo []ToolInfo) [][]bool {
+	waits := make([][]bool, len(toolsInfo))
+	for i, info := range toolsInfo {
+		waits[i] = make([]bool, len(toolsInfo))
+		for j, other := range toolsInfo {
+			waits[i][j] = i != j && toolDependsOn(info, other)
+		}
+	}
+	for k := range toolsInfo {
+		for i := range toolsInfo {
+			for j := range toolsInfo {
+				if waits[i][k] && waits[k][j] {
+					waits[i][j] = true
+				}
+			}
+		}
+	}
+	return waits
+}
+
+func samePuppetVersion(a ToolInfo, b ToolInfo) bool {
+	if a.PuppetVersion == nil || b.PuppetVersion == nil {
+		return a.PuppetVersion == b.PuppetVersion
+	}
+	return a.PuppetVersion.Equal(b.PuppetVersion)
+}
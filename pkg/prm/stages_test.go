@@ -0,0 +1,182 @@
+package prm_test
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver"
+	"github.com/puppetlabs/prm/internal/pkg/mock"
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrm_Validate_Needs(t *testing.T) {
+	outputDir := "path/to/code/.prm-validate"
+	tool := func(id string, stage int, needs ...string) prm.ToolInfo {
+		info := CreateToolInfo(id, "puppetlabs", "0.1.0", nil)
+		info.Stage = stage
+		info.Needs = needs
+		return info
+	}
+	tests := []struct {
+		name          string
+		tools         []prm.ToolInfo
+		failing       []string
+		wantValidated []string
+		wantResults   []string
+		wantSkipped   []string
+		wantErrMsg    string
+	}{
+		{
+			name:          "A tool runs after the tools it needs",
+			tools:         []prm.ToolInfo{tool("spec", 0, "puppetlabs/cache"), tool("cache", 0)},
+			wantValidated: []string{"cache", "spec"},
+			wantResults:   []string{"passed", "passed"},
+			wantSkipped:   []string{"", ""},
+		},
+		{
+			name:          "The tools of a stage run after those of the earlier stages",
+			tools:         []prm.ToolInfo{tool("spec", 2), tool("cache", 1), tool("lint", 0)},
+			wantValidated: []string{"cache", "lint", "spec"},
+			wantResults:   []string{"passed", "passed", "passed"},
+			wantSkipped:   []string{"", "", ""},
+		},
+		{
+			name:          "The tools that need a failing tool are skipped",
+			tools:         []prm.ToolInfo{tool("cache", 0), tool("prep", 0, "puppetlabs/cache"), tool("spec", 0, "puppetlabs/prep"), tool("lint", 0)},
+			failing:       []string{"cache"},
+			wantValidated: []string{"cache", "lint"},
+			wantResults:   []string{"failed", "skipped", "skipped", "passed"},
+			wantSkipped:   []string{"", "cache failed", "prep was skipped", ""},
+			wantErrMsg:    "Validation returned 1 error",
+		},
+		{
+			name:          "The last tools are skipped once the tool they need fails",
+			tools:         []prm.ToolInfo{tool("cache", 0), tool("spec", 0, "puppetlabs/cache")},
+			failing:       []string{"cache"},
+			wantValidated: []string{"cache"},
+			wantResults:   []string{"failed", "skipped"},
+			wantSkipped:   []string{"", "cache failed"},
+			wantErrMsg:    "Validation returned 1 error",
+		},
+		{
+			name:          "The tools of later stages are skipped after a failure",
+			tools:         []prm.ToolInfo{tool("cache", 1), tool("spec", 2), tool("lint", 0)},
+			failing:       []string{"cache"},
+			wantValidated: []string{"cache", "lint"},
+			wantResults:   []string{"failed", "skipped", "passed"},
+			wantSkipped:   []string{"", "cache failed", ""},
+			wantErrMsg:    "Validation returned 1 error",
+		},
+		{
+			name:          "Tools that need each other still run",
+			tools:         []prm.ToolInfo{tool("spec", 0, "puppetlabs/cache"), tool("cache", 0, "puppetlabs/spec"), tool("lint", 0)},
+			wantValidated: []string{"lint", "spec", "cache"},
+			wantResults:   []string{"passed", "passed", "passed"},
+			wantSkipped:   []string{"", "", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			afs := &afero.Afero{Fs: fs}
+			backend := &mock.MockBackend{StatusIsAvailable: true, ToolAvalible: true, ValidateReturn: "PASS", ValidateReturns: map[string]string{}}
+			for _, id := range tt.failing {
+				backend.ValidateReturns[id] = "FAIL"
+			}
+			p := &prm.Prm{AFS: afs, IOFS: &afero.IOFS{Fs: fs}, CodeDir: "path/to/code", Backend: backend}
+
+			err := p.Validate(context.Background(), tt.tools, 1, prm.OutputSettings{ResultsView: "terminal", OutputDir: outputDir, ReportFormat: prm.ReportFormatJson})
+			if tt.wantErrMsg != "" {
+				assert.EqualError(t, err, tt.wantErrMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantValidated, backend.ValidatedTools)
+
+			content, err := afs.ReadFile(filepath.Join(outputDir, "report.json"))
+			assert.NoError(t, err)
+			var report struct {
+				Tools []struct {
+					Result  string `json:"result"`
+					Skipped string `json:"skipped"`
+				} `json:"tools"`
+			}
+			assert.NoError(t, json.Unmarshal(content, &report))
+			var results, skipped []string
+			for _, tool := range report.Tools {
+				results = append(results, tool.Result)
+				skipped = append(skipped, tool.Skipped)
+			}
+			assert.Equal(t, tt.wantResults, results)
+			assert.Equal(t, tt.wantSkipped, skipped)
+		})
+	}
+}
+
+func TestPrm_Validate_Needs_PuppetMatrix(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afs := &afero.Afero{Fs: fs}
+	backend := &mock.MockBackend{StatusIsAvailable: true, ToolAvalible: true, ValidateReturn: "PASS"}
+	p := &prm.Prm{AFS: afs, IOFS: &afero.IOFS{Fs: fs}, CodeDir: "path/to/code", Backend: backend}
+
+	cache := CreateToolInfo("cache", "puppetlabs", "0.1.0", nil)
+	cache.Stage = 1
+	spec := CreateToolInfo("spec", "puppetlabs", "0.1.0", nil)
+	spec.Stage = 2
+	versions := []*semver.Version{semver.MustParse("6.28.0"), semver.MustParse("7.15.0")}
+	tools := prm.ExpandPuppetMatrix([]prm.ToolInfo{spec, cache}, versions)
+
+	err := p.Validate(context.Background(), tools, 1, prm.OutputSettings{ResultsView: "terminal"})
+	assert.NoError(t, err)
+	// The spec of each version runs once the cache of that version has
+	assert.Equal(t, []string{"cache", "cache", "spec", "spec"}, backend.ValidatedTools)
+	assert.Equal(t, []string{"6.28.0", "7.15.0", "6.28.0", "7.15.0"}, backend.ValidatePuppetVersions)
+}
+
+func TestPrm_Validate_InvocationIDs(t *testing.T) {
+	outputDir := "path/to/code/.prm-validate"
+	fs := afero.NewMemMapFs()
+	afs := &afero.Afero{Fs: fs}
+	backend := &mock.MockBackend{StatusIsAvailable: true, ToolAvalible: true, ValidateReturn: "FAIL"}
+	p := &prm.Prm{AFS: afs, IOFS: &afero.IOFS{Fs: fs}, CodeDir: "path/to/code", Backend: backend}
+
+	prep := CreateToolInfo("spec", "puppetlabs", "0.1.0", []string{"spec_prep"})
+	prep.ID = "spec_prep"
+	spec := CreateToolInfo("spec", "puppetlabs", "0.1.0", nil)
+	spec.ID = "spec"
+
+	err := p.Validate(context.Background(), []prm.ToolInfo{prep, spec}, 2, prm.OutputSettings{ResultsView: "file", OutputDir: outputDir, ReportFormat: prm.ReportFormatJson})
+	assert.EqualError(t, err, "Validation returned 2 errors")
+
+	content, err := afs.ReadFile(filepath.Join(outputDir, "report.json"))
+	assert.NoError(t, err)
+	var report struct {
+		Tools []struct {
+			Name string `json:"name"`
+		} `json:"tools"`
+	}
+	assert.NoError(t, json.Unmarshal(content, &report))
+	assert.Len(t, report.Tools, 2)
+	assert.Equal(t, "spec_prep", report.Tools[0].Name)
+	assert.Equal(t, "spec", report.Tools[1].Name)
+
+	// Each run of the tool logs to a file of its own
+	logs, err := afs.ReadDir(outputDir)
+	assert.NoError(t, err)
+	var prepLogs, specLogs int
+	for _, log := range logs {
+		switch {
+		case strings.HasPrefix(log.Name(), "spec_prep_"):
+			prepLogs++
+		case strings.HasPrefix(log.Name(), "spec_"):
+			specLogs++
+		}
+	}
+	assert.Equal(t, 1, prepLogs)
+	assert.Equal(t, 1, specLogs)
+}
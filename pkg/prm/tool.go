@@ -74,7 +74,7 @@ type PuppetConfig struct {
 
 type CommonConfig struct {
 	CanValidate         bool              `mapstructure:"can_validate"`
-	NeedsWriteAccess    bool              `mapstructure:"needs_write_access"`
+	NeedsWriteAccess    *bool             `mapstructure:"needs_write_access"`
 	UseScript           string            `mapstructure:"use_script"`
 	RequiresGit         bool              `mapstructure:"requires_git"`
 	DefaultArgs         []string          `mapstructure:"default_args"`
@@ -83,6 +83,20 @@ type CommonConfig struct {
 	InterleaveStdOutErr bool              `mapstructure:"interleave_stdout"`
 	OutputMode          *OutputModes      `mapstructure:"output_mode"`
 	Env                 map[string]string `mapstructure:"env"`
+	// Globs of the files the tool validates, e.g. "**/*.pp"; used to decide
+	// which tools to re-run when files change
+	FilePatterns []string `mapstructure:"file_patterns"`
+	// Seconds to wait for the tool before stopping it, in place of the toolTimeout setting
+	Timeout int `mapstructure:"timeout"`
+	// The CPUs and memory, e.g. "512m", the tool's container may use; unlimited when unset
+	Cpus   float64 `mapstructure:"cpus"`
+	Memory string  `mapstructure:"memory"`
+}
+
+// Tools are given write access to the code directory
+// unless they explicitly declare that they do not need it
+func (c CommonConfig) CodeDirReadOnly() bool {
+	return c.NeedsWriteAccess != nil && !*c.NeedsWriteAccess
 }
 
 type OutputModes struct {
@@ -1,5 +1,14 @@
 package prm
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/olekukonko/tablewriter"
+)
+
 /*
   This package contains ToolGroups, which defines
   what inidividual tools make up a specific ToolGroup.
@@ -7,24 +16,44 @@ package prm
   This allows users to specify 'group/mygroup' and have PRM
   execute against a larger list of tools, without needing to define
   or understand what is being called.
+
+  A built-in group can be run by its ID, and referenced from the tools
+  of a validate.yml group, e.g. '- name: group/modules', which runs
+  every tool of the built-in group in its place.
 */
 type ToolInst struct {
-	Name string   `yaml:"name"`
-	Args []string `yaml:"args"`
+	Name string   `yaml:"name" json:"name"`
+	Args []string `yaml:"args" json:"args,omitempty"`
+	// Identifies this run of the tool in results, logs and needs, so a tool
+	// can run more than once in a group. Defaults to the ID of the tool.
+	ID string `yaml:"id" json:"id,omitempty"`
+	// Environment variables set for the tool, in addition to those of its prm-config.yml
+	Env map[string]string `yaml:"env" json:"env,omitempty"`
+	// Seconds to wait for the tool before stopping it, in place of the --toolTimeout flag
+	Timeout int `yaml:"timeout" json:"timeout,omitempty"`
+	// The CPUs and memory, e.g. "2g", the tool's container may use, in place of those of its prm-config.yml
+	Cpus   float64 `yaml:"cpus" json:"cpus,omitempty"`
+	Memory string  `yaml:"memory" json:"memory,omitempty"`
+	// Names of the tools of the group, or IDs of their runs, that must pass before this one runs
+	Needs []string `yaml:"needs" json:"needs,omitempty"`
+	// The stage of the group the tool runs in, counting from 1, or 0 outside of the stages
+	Stage int `yaml:"-" json:"stage,omitempty"`
 }
 
 var (
 	ToolGroups = map[string][]ToolInst{
 		// TODO: we may need to define group as a reserved word
 		"group/modules": {
-			{Name: "puppetlabs/spec_cache"},
+			{Name: "puppetlabs/spec_cache", Stage: 1},
 			{
 				Name: "puppetlabs/spec_puppet",
 				Args: []string{
 					"spec_prep",
 				},
+				ID:    "spec_prep",
+				Stage: 2,
 			},
-			{Name: "puppetlabs/spec_puppet"},
+			{Name: "puppetlabs/spec_puppet", Stage: 3},
 			{Name: "puppetlabs/rubocop"},
 			{Name: "puppetlabs/puppet-lint"},
 			{Name: "puppetlabs/puppet-syntax"},
@@ -33,6 +62,134 @@ var (
 	}
 )
 
+// The prefix reserved for the IDs of built-in tool groups
+const BuiltinGroupPrefix = "group/"
+
+// IsBuiltinGroup reports whether id names one of the built-in ToolGroups
+func IsBuiltinGroup(id string) bool {
+	_, ok := ToolGroups[id]
+	return ok
+}
+
+// BuiltinGroups returns the built-in ToolGroups, sorted by ID
+func BuiltinGroups() []Group {
+	var groups []Group
+	for id, tools := range ToolGroups {
+		groups = append(groups, Group{ID: id, Tools: tools, Builtin: true})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].ID < groups[j].ID
+	})
+	return groups
+}
+
+// Replaces the tools that reference a built-in group with the tools of that group.
+// A tool listed alongside a built-in group that already runs it with the same args is a duplicate.
+func expandBuiltinGroups(tools []ToolInst) ([]ToolInst, error) {
+	var expanded []ToolInst
+	from := make(map[int]string)
+	for _, tool := range tools {
+		if !strings.HasPrefix(tool.Name, BuiltinGroupPrefix) {
+			expanded = append(expanded, tool)
+			continue
+		}
+		groupTools, ok := ToolGroups[tool.Name]
+		if !ok {
+			return nil, fmt.Errorf("built-in tool group '%s' not found", tool.Name)
+		}
+		for _, groupTool := range groupTools {
+			from[len(expanded)] = tool.Name
+			expanded = append(expanded, groupTool)
+		}
+	}
+
+	for i, tool := range expanded {
+		for j := 0; j < i; j++ {
+			if !compareToolInst(tool, expanded[j]) || (from[i] == "" && from[j] == "") {
+				continue
+			}
+			if from[i] == "" || from[j] == "" {
+				return nil, fmt.Errorf("duplicate tool '%s' found. It is already run by the built-in tool group '%s'", tool.Name, from[i]+from[j])
+			}
+			return nil, fmt.Errorf("duplicate tool '%s' found. It is run by both the '%s' and '%s' built-in tool groups", tool.Name, from[j], from[i])
+		}
+	}
+	return expanded, nil
+}
+
+// The ID of the run of the tool: its own ID, or else the ID of the tool
+func (t ToolInst) invocationID() string {
+	if t.ID != "" {
+		return t.ID
+	}
+	name, _, _ := strings.Cut(t.Name, "@")
+	_, id, _ := strings.Cut(name, "/")
+	return id
+}
+
+// Gives each tool a unique ID. Tools without an ID of their own take the
+// ID of the tool, followed by _2, _3 and so on when the tool runs more than once.
+func assignInvocationIDs(group Group) ([]ToolInst, error) {
+	taken := make(map[string]bool)
+	for _, tool := range group.Tools {
+		if tool.ID == "" {
+			continue
+		}
+		if taken[tool.ID] {
+			return nil, fmt.Errorf("duplicate id '%s' found in the tool group '%s'. Each run of a tool needs an id of its own", tool.ID, group.ID)
+		}
+		taken[tool.ID] = true
+	}
+
+	tools := make([]ToolInst, len(group.Tools))
+	for i, tool := range group.Tools {
+		if tool.ID == "" {
+			id := tool.invocationID()
+			for n := 2; taken[id]; n++ {
+				id = fmt.Sprintf("%s_%d", tool.invocationID(), n)
+			}
+			tool.ID = id
+			taken[id] = true
+		}
+		tools[i] = tool
+	}
+	return tools, nil
+}
+
+// FormatGroups formats the validation groups to display on the console
+// in table format or json format
+func FormatGroups(groups []Group, outputFormat string) (string, error) {
+	switch outputFormat {
+	case "table":
+		stringBuilder := &strings.Builder{}
+		table := tablewriter.NewWriter(stringBuilder)
+		table.SetHeader([]string{"Group", "Source", "Uses", "Tools"})
+		table.SetBorder(false)
+		table.SetAutoWrapText(false)
+		for _, group := range groups {
+			source := "validate.yml"
+			if group.Builtin {
+				source = "built-in"
+			}
+			var names []string
+			for _, tool := range append(group.Tools, group.stageTools()...) {
+				names = append(names, strings.TrimSpace(tool.Name+" "+strings.Join(tool.Args, " ")))
+			}
+			uses := group.Include
+			if group.Extends != "" {
+				uses = append([]string{group.Extends}, group.Include...)
+			}
+			table.Append([]string{group.ID, source, strings.Join(uses, ", "), strings.Join(names, ", ")})
+		}
+		table.Render()
+		return stringBuilder.String(), nil
+	case "json":
+		prettyJSON, _ := jsoniter.ConfigFastest.MarshalIndent(&groups, "", "  ")
+		return string(prettyJSON), nil
+	}
+	return "", fmt.Errorf("unknown format '%s', must be one of [table|json]", outputFormat)
+}
+
 func compareToolInst(t1 ToolInst, t2 ToolInst) bool {
 	return t1.Name == t2.Name && equal(t1.Args, t2.Args)
 }
@@ -0,0 +1,131 @@
+package prm_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrm_GetValidationGroupFromFile_Builtin(t *testing.T) {
+	tests := []struct {
+		name          string
+		validateYml   string
+		selectedGroup string
+		expectedTools []prm.ToolInst
+		expectedErr   string
+	}{
+		{
+			name:          "Should select a built-in group without a validate.yml",
+			selectedGroup: "group/modules",
+			expectedTools: modulesTools(),
+		},
+		{
+			name:        "Should error without a validate.yml or a selected group",
+			expectedErr: "validate.yml",
+		},
+		{
+			name:          "Should error for a group that is not built-in without a validate.yml",
+			selectedGroup: "ci",
+			expectedErr:   "validate.yml",
+		},
+		{
+			name:          "Should select a built-in group not defined in validate.yml",
+			validateYml:   "groups:\n  - id: ci\n    tools:\n      - name: puppetlabs/epp\n",
+			selectedGroup: "group/modules",
+			expectedTools: modulesTools(),
+		},
+		{
+			name:          "Should prefer a validate.yml group with the ID of a built-in group",
+			validateYml:   "groups:\n  - id: group/modules\n    tools:\n      - name: puppetlabs/epp\n",
+			selectedGroup: "group/modules",
+			expectedTools: []prm.ToolInst{{Name: "puppetlabs/epp", ID: "epp"}},
+		},
+		{
+			name:          "Should expand a reference to a built-in group",
+			validateYml:   "groups:\n  - id: ci\n    tools:\n      - name: group/modules\n      - name: puppetlabs/epp\n        args: [--strict]\n",
+			selectedGroup: "ci",
+			expectedTools: append(append([]prm.ToolInst{}, modulesTools()...), prm.ToolInst{Name: "puppetlabs/epp", ID: "epp", Args: []string{"--strict"}}),
+		},
+		{
+			name:          "Should error for a reference to an unknown built-in group",
+			validateYml:   "groups:\n  - id: ci\n    tools:\n      - name: group/unknown\n",
+			selectedGroup: "ci",
+			expectedErr:   "built-in tool group 'group/unknown' not found",
+		},
+		{
+			name:          "Should error for a tool already run by a referenced built-in group",
+			validateYml:   "groups:\n  - id: ci\n    tools:\n      - name: group/modules\n      - name: puppetlabs/rubocop\n",
+			selectedGroup: "ci",
+			expectedErr:   "duplicate tool 'puppetlabs/rubocop' found",
+		},
+		{
+			name:          "Should error for a built-in group referenced twice",
+			validateYml:   "groups:\n  - id: ci\n    tools:\n      - name: group/modules\n      - name: group/modules\n",
+			selectedGroup: "ci",
+			expectedErr:   "duplicate tool 'group/modules' found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := stubInstalledTools(t)
+			p.CodeDir = "path/to/code"
+			p.AFS.MkdirAll(p.CodeDir, 0750) //nolint:errcheck
+			if tt.validateYml != "" {
+				p.AFS.WriteFile(filepath.Join(p.CodeDir, "validate.yml"), []byte(tt.validateYml), 0644) //nolint:errcheck
+			}
+
+			group, err := p.GetValidationGroupFromFile(tt.selectedGroup)
+			if tt.expectedErr != "" {
+				assert.ErrorContains(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.selectedGroup, group.ID)
+			assert.Equal(t, tt.expectedTools, group.Tools)
+		})
+	}
+}
+
+func TestPrm_ValidationGroups(t *testing.T) {
+	p := stubInstalledTools(t)
+	p.CodeDir = "path/to/code"
+	p.AFS.MkdirAll(p.CodeDir, 0750) //nolint:errcheck
+
+	groups, err := p.ValidationGroups()
+	assert.NoError(t, err)
+	assert.Equal(t, prm.BuiltinGroups(), groups)
+
+	validateYml := "groups:\n  - id: ci\n    tools:\n      - name: group/modules\n"
+	p.AFS.WriteFile(filepath.Join(p.CodeDir, "validate.yml"), []byte(validateYml), 0644) //nolint:errcheck
+	groups, err = p.ValidationGroups()
+	assert.NoError(t, err)
+	assert.Equal(t, append([]prm.Group{{ID: "ci", Tools: []prm.ToolInst{{Name: "group/modules"}}}}, prm.BuiltinGroups()...), groups)
+
+	output, err := prm.FormatGroups(groups, "table")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "validate.yml")
+	assert.Contains(t, output, "built-in")
+	assert.Contains(t, output, "puppetlabs/spec_puppet spec_prep")
+
+	output, err = prm.FormatGroups(groups, "json")
+	assert.NoError(t, err)
+	assert.Contains(t, output, `"builtin": true`)
+
+	_, err = prm.FormatGroups(groups, "yaml")
+	assert.Error(t, err)
+}
+
+// The tools of group/modules, with the IDs they are given once resolved
+func modulesTools() []prm.ToolInst {
+	return []prm.ToolInst{
+		{Name: "puppetlabs/spec_cache", ID: "spec_cache", Stage: 1},
+		{Name: "puppetlabs/spec_puppet", ID: "spec_prep", Args: []string{"spec_prep"}, Stage: 2},
+		{Name: "puppetlabs/spec_puppet", ID: "spec_puppet", Stage: 3},
+		{Name: "puppetlabs/rubocop", ID: "rubocop"},
+		{Name: "puppetlabs/puppet-lint", ID: "puppet-lint"},
+		{Name: "puppetlabs/puppet-syntax", ID: "puppet-syntax"},
+		{Name: "puppetlabs/puppet-strings", ID: "puppet-strings"},
+	}
+}
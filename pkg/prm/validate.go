@@ -2,6 +2,7 @@
 package prm
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -24,12 +25,16 @@ const (
 )
 
 var (
+	ErrValidationCancelled = errors.New("validation was cancelled before every tool ran")
+
 	toolLogOutputPaths map[string]string // Key = toolName, Value = logFilePath, stores each tool's log file path
 )
 
-func (p *Prm) Validate(toolsInfo []ToolInfo, workerCount int, settings OutputSettings) error {
+// Validate runs the tools and reports their results. Tools still to run,
+// or running, when ctx is done are cancelled.
+func (p *Prm) Validate(ctx context.Context, toolsInfo []ToolInfo, workerCount int, settings OutputSettings) error {
 	if status := p.Backend.Status(); !status.IsAvailable {
-		return ErrDockerNotRunning
+		return p.errBackendNotRunning()
 	}
 
 	if len(toolsInfo) == 0 {
@@ -37,49 +42,254 @@ func (p *Prm) Validate(toolsInfo []ToolInfo, workerCount int, settings OutputSet
 	}
 	toolLogOutputPaths = make(map[string]string)
 
-	tasks := p.createTasks(toolsInfo)
+	tasks, err := p.runTasks(ctx, toolsInfo, workerCount, settings)
+	if err != nil {
+		return err
+	}
+
+	err = p.outputResults(tasks, settings, isPuppetMatrix(toolsInfo))
+	if err == nil && ctx.Err() != nil {
+		return ErrValidationCancelled
+	}
+	// Tools that never ran leave the content unvalidated, even
+	// when the failure that stopped them is in the baseline
+	if notRun := getNotRunCount(tasks); err == nil && notRun > 0 {
+		return errors.New(getNotRunMessage(notRun))
+	}
+	return err
+}
+
+// Runs the tools and collects their results, less any failures in the baseline.
+// Tools still to run, or running, when ctx is done are cancelled.
+func (p *Prm) runTasks(ctx context.Context, toolsInfo []ToolInfo, workerCount int, settings OutputSettings) ([]*Task[ValidationOutput], error) {
+	var cache *resultCache
+	if settings.UseResultCache {
+		var err error
+		cache, err = p.newResultCache()
+		if err != nil {
+			log.Warn().Msgf("Unable to cache validation results: %s", err)
+		}
+	}
+
+	// Baselined failures are suppressed as each tool finishes, so that they
+	// neither cancel the other tools nor skip those that need the tool. When
+	// writing the baseline, every failure is recorded before it is suppressed.
+	var baseline *Baseline
+	if !settings.WriteBaseline {
+		var err error
+		baseline, err = p.readBaseline()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tasks := p.createTasks(toolsInfo, cache, baseline)
 
 	pool := CreateWorkerPool(tasks, workerCount)
-	pool.Run()
+	pool.SkipWhen = func(output ValidationOutput) bool {
+		switch resultName(output) {
+		case "failed", "error", "skipped":
+			return true
+		}
+		return false
+	}
+	pool.Skip = skippedOutput
+	if settings.FailFast {
+		pool.CancelWhen = func(output ValidationOutput) bool {
+			return output.err != nil && (output.exitCode == VALIDATION_FAILED || output.exitCode == VALIDATION_ERROR)
+		}
+	}
+	pool.Run(ctx)
 
-	err := p.outputResults(tasks, settings)
-	return err
+	if settings.WriteBaseline {
+		if err := p.writeBaseline(tasks); err != nil {
+			return nil, err
+		}
+		if err := p.applyBaseline(tasks); err != nil {
+			return nil, err
+		}
+	}
+	return tasks, nil
 }
 
-func (p Prm) taskFunc(tool ToolInfo) func() ValidationOutput {
-	return func() ValidationOutput {
-		toolName := tool.Tool.Cfg.Plugin.Id
+// Returns the tools that may write to the code directory
+// while other tools are validating it in parallel. Tools
+// ordered by their stages or needs never run at the same time.
+func ParallelCodeDirWriters(toolsInfo []ToolInfo, workerCount int) []string {
+	if workerCount < 2 || len(toolsInfo) < 2 {
+		return nil
+	}
+
+	waits := toolWaits(toolsInfo)
+	var writers []string
+	seen := map[string]bool{}
+	for i, info := range toolsInfo {
+		name := fmt.Sprintf("%s/%s", info.Tool.Cfg.Plugin.Author, info.Tool.Cfg.Plugin.Id)
+		if info.Tool.Cfg.Common.CodeDirReadOnly() || seen[name] {
+			continue
+		}
+		for j := range toolsInfo {
+			if i != j && !waits[i][j] && !waits[j][i] {
+				seen[name] = true
+				writers = append(writers, name)
+				break
+			}
+		}
+	}
+	return writers
+}
+
+func (p Prm) taskFunc(name string, tool ToolInfo, cache *resultCache, baseline *Baseline) func(ctx context.Context) ValidationOutput {
+	return func(ctx context.Context) ValidationOutput {
+		toolName := tool.invocationID()
+		if ctx.Err() != nil {
+			return cancelledOutput()
+		}
 		log.Info().Msgf("Validating with the %s tool", toolName)
-		output := ValidationOutput{err: nil, exitCode: 0}
+		start := time.Now()
+		config := p.toolConfig(tool)
+		puppetVersion := ""
+		if config.PuppetVersion != nil {
+			puppetVersion = config.PuppetVersion.String()
+		}
 
-		err := p.Backend.GetTool(tool.Tool, p.RunningConfig)
+		err := p.Backend.GetTool(tool.Tool, config)
 		if err != nil {
 			log.Error().Msgf("Failed to validate with tool: %s/%s", tool.Tool.Cfg.Plugin.Author, tool.Tool.Cfg.Plugin.Id)
-			output = ValidationOutput{err: err, exitCode: VALIDATION_ERROR}
-			return output
+			return ValidationOutput{err: err, exitCode: VALIDATION_ERROR, toolExitCode: NoExitCode, duration: time.Since(start), puppetVersion: puppetVersion}
 		}
 
-		exitCode, stdout, err := p.Backend.Validate(tool, p.RunningConfig, DirectoryPaths{codeDir: p.CodeDir, cacheDir: p.CacheDir})
+		// Ask the tool for structured output if it supports it
+		outputMode, args, err := withOutputModeArgs(tool.Tool, tool.Args)
 		if err != nil {
-			output = ValidationOutput{err: err, exitCode: exitCode, stdout: stdout}
+			return ValidationOutput{err: fmt.Errorf("invalid output_mode for %s: %s", toolName, err), exitCode: VALIDATION_ERROR, toolExitCode: NoExitCode, duration: time.Since(start), puppetVersion: puppetVersion}
+		}
+		tool.Args = args
+
+		cacheKey := ""
+		if cache != nil {
+			cacheKey, err = cache.key(tool, config)
+			if err != nil {
+				log.Warn().Msgf("Unable to look up the cached result of the %s tool: %s", toolName, err)
+			} else if output, ok := cache.get(cacheKey); ok {
+				log.Info().Msgf("Using the cached result of the %s tool", toolName)
+				output.puppetVersion = puppetVersion
+				return baseline.suppress(name, output)
+			}
+		}
+
+		exitCode, toolOutput, err := p.Backend.Validate(ctx, tool, config, DirectoryPaths{codeDir: p.CodeDir, cacheDir: p.CacheDir})
+		if ctx.Err() != nil {
+			log.Info().Msgf("Cancelled validation with the %s tool", toolName)
+			output := cancelledOutput()
+			output.duration = time.Since(start)
+			output.puppetVersion = puppetVersion
 			return output
 		}
-		output.stdout = stdout
-		return output
+		output := ValidationOutput{
+			err:           err,
+			exitCode:      exitCode,
+			stdout:        toolOutput.Stdout,
+			stderr:        toolOutput.Stderr,
+			toolExitCode:  toolOutput.ExitCode,
+			duration:      time.Since(start),
+			puppetVersion: puppetVersion,
+		}
+
+		if outputMode != "" {
+			findings, parseErr := parseFindings(outputMode, toolOutput.Stdout)
+			if parseErr != nil {
+				log.Warn().Msgf("Unable to read findings from the %s tool: %s", toolName, parseErr)
+			}
+			output.findings = findings
+		}
+
+		// Errors running the tool are not a verdict on the content, so are not cached
+		if cacheKey != "" && exitCode != VALIDATION_ERROR {
+			cache.put(cacheKey, output)
+		}
+
+		return baseline.suppress(name, output)
+	}
+}
+
+// The result of a tool that was not run because a tool it needs did not pass.
+// Like a cancelled tool, it is not counted as an error.
+func skippedOutput(task *Task[ValidationOutput], need *Task[ValidationOutput]) ValidationOutput {
+	var reason string
+	switch resultName(need.Output) {
+	case "failed":
+		reason = fmt.Sprintf("%s failed", need.Name)
+	case "skipped":
+		reason = fmt.Sprintf("%s was skipped", need.Name)
+	default:
+		reason = fmt.Sprintf("%s errored", need.Name)
 	}
+	log.Info().Msgf("Skipped validation with the %s tool: %s", task.Name, reason)
+	return ValidationOutput{exitCode: VALIDATION_ERROR, toolExitCode: NoExitCode, skipped: reason}
+}
+
+// The result of a tool that was stopped, or never started, because validation was cancelled.
+// It is not counted as an error; the failure that caused the cancellation is.
+func cancelledOutput() ValidationOutput {
+	return ValidationOutput{exitCode: VALIDATION_ERROR, toolExitCode: NoExitCode, cancelled: true}
 }
 
-func (p *Prm) outputResults(tasks []*Task[ValidationOutput], settings OutputSettings) error {
+func (p *Prm) outputResults(tasks []*Task[ValidationOutput], settings OutputSettings, showPuppetVersion bool) error {
 	err := p.writeOutputLogs(tasks, settings)
 	if err != nil {
 		return err
 	}
 
+	return p.summariseResults(tasks, settings, showPuppetVersion)
+}
+
+// Writes the report and results table for the tools' latest results
+func (p *Prm) summariseResults(tasks []*Task[ValidationOutput], settings OutputSettings, showPuppetVersion bool) error {
+	err := p.writeReport(tasks, settings)
+	if err != nil {
+		return err
+	}
+
 	tableContents := createTableContents(tasks, settings.ResultsView)
 	headers := []string{"Tool Name", "Validation Exit Code"}
 	if settings.ResultsView == "file" {
 		headers = append(headers, "File Location")
 	}
+	if showPuppetVersion {
+		headers = append(headers[:1], append([]string{"Puppet Version"}, headers[1:]...)...)
+		for i, task := range tasks {
+			tableContents[i] = append(tableContents[i][:1], append([]string{task.Output.puppetVersion}, tableContents[i][1:]...)...)
+		}
+	}
+	if hasSkippedResults(tasks) {
+		headers = append(headers, "Skipped Because")
+		for i, task := range tasks {
+			tableContents[i] = append(tableContents[i], task.Output.skipped)
+		}
+	}
+	if hasCachedResults(tasks) {
+		headers = append(headers, "Cached")
+		for i, task := range tasks {
+			cached := ""
+			if task.Output.cached {
+				cached = "cached"
+			}
+			tableContents[i] = append(tableContents[i], cached)
+		}
+	}
+	if hasBaselinedResults(tasks) {
+		headers = append(headers, "Baseline")
+		for i, task := range tasks {
+			tableContents[i] = append(tableContents[i], baselineSummary(task.Output))
+		}
+	}
+	if hasFindings(tasks) {
+		headers = append(headers, "Findings")
+		for i, task := range tasks {
+			tableContents[i] = append(tableContents[i], findingsSummary(task.Output))
+		}
+	}
 	renderTable(headers, tableContents)
 
 	if errorCount := getErrorCount(tasks); errorCount > 0 {
@@ -97,7 +307,9 @@ func writeOutputToTerminal(tasks []*Task[ValidationOutput]) {
 		}
 
 		var errText string
-		if output.err.Error() != "" {
+		if len(output.findings) > 0 {
+			errText = formatFindings(output.findings)
+		} else if output.err.Error() != "" {
 			errText = output.err.Error()
 		} else {
 			errText = output.stdout
@@ -126,11 +338,26 @@ func renderTable(headers []string, data [][]string) {
 	table.Render()
 }
 
-func (p *Prm) createTasks(toolsInfo []ToolInfo) []*Task[ValidationOutput] {
+// The ID of the run of the tool, which names its results and logs
+func (info ToolInfo) invocationID() string {
+	if info.ID != "" {
+		return info.ID
+	}
+	return info.Tool.Cfg.Plugin.Id
+}
+
+func (p *Prm) createTasks(toolsInfo []ToolInfo, cache *resultCache, baseline *Baseline) []*Task[ValidationOutput] {
 	tasks := make([]*Task[ValidationOutput], len(toolsInfo))
+	matrix := isPuppetMatrix(toolsInfo)
 	for i, info := range toolsInfo {
-		tasks[i] = CreateTask[ValidationOutput](info.Tool.Cfg.Plugin.Id, p.taskFunc(info), ValidationOutput{})
+		name := info.invocationID()
+		// Keep results and logs for each Puppet version apart
+		if matrix && info.PuppetVersion != nil {
+			name = fmt.Sprintf("%s_puppet-%s", name, info.PuppetVersion)
+		}
+		tasks[i] = CreateTask[ValidationOutput](name, p.taskFunc(name, info, cache, baseline), ValidationOutput{})
 	}
+	linkTaskNeeds(tasks, toolsInfo)
 	return tasks
 }
 
@@ -191,6 +418,10 @@ func writeStringToFile(file afero.File, output ValidationOutput) error {
 	}
 	stdout := cleanOutput(output.stdout)
 
+	if len(output.findings) > 0 {
+		stdout = fmt.Sprintf("%s\n\n%s", formatFindings(output.findings), stdout)
+	}
+
 	_, err := file.WriteString(fmt.Sprintf("%s\n%s", stdout, errText))
 	if err != nil {
 		return err
@@ -213,6 +444,17 @@ func (p *Prm) writeOutputLogs(tasks []*Task[ValidationOutput], settings OutputSe
 	return fmt.Errorf("invalid --resultsView flag specified")
 }
 
+// Counts the tools that were cancelled, or skipped because a tool they need did not pass
+func getNotRunCount(tasks []*Task[ValidationOutput]) (count int) {
+	for _, task := range tasks {
+		output := task.Output
+		if output.cancelled || output.skipped != "" {
+			count++
+		}
+	}
+	return count
+}
+
 func getErrorCount(tasks []*Task[ValidationOutput]) (count int) {
 	for _, task := range tasks {
 		output := task.Output
@@ -226,15 +468,21 @@ func getErrorCount(tasks []*Task[ValidationOutput]) (count int) {
 func createTableContents(tasks []*Task[ValidationOutput], resultsView string) (tableContents [][]string) {
 	for _, task := range tasks {
 		output := task.Output
+		exitCode := fmt.Sprintf("%d", output.exitCode)
+		if output.cancelled {
+			exitCode = "cancelled"
+		} else if output.skipped != "" {
+			exitCode = "skipped"
+		}
 		if resultsView == "file" { // Will also include the path to each
 			outputPath := toolLogOutputPaths[task.Name]
 			// Shortens the output file path so table doesn't become unreadable as a result of long file paths
 			if shortOutputDir := strings.Split(outputPath, ".prm-validate"); len(shortOutputDir) == 2 {
 				outputPath = fmt.Sprint(".prm-validate", shortOutputDir[1])
 			}
-			tableContents = append(tableContents, []string{task.Name, fmt.Sprintf("%d", output.exitCode), outputPath})
+			tableContents = append(tableContents, []string{task.Name, exitCode, outputPath})
 		} else {
-			tableContents = append(tableContents, []string{task.Name, fmt.Sprintf("%d", output.exitCode)})
+			tableContents = append(tableContents, []string{task.Name, exitCode})
 		}
 	}
 	return tableContents
@@ -248,3 +496,79 @@ func getErrorMessage(count int) string {
 
 	return fmt.Sprintf("Validation returned %d %s", count, spelling)
 }
+
+func getNotRunMessage(count int) string {
+	spelling := "tools"
+	if count == 1 {
+		spelling = "tool"
+	}
+
+	return fmt.Sprintf("Validation did not run %d %s after a failure", count, spelling)
+}
+
+func hasSkippedResults(tasks []*Task[ValidationOutput]) bool {
+	for _, task := range tasks {
+		if task.Output.skipped != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func hasCachedResults(tasks []*Task[ValidationOutput]) bool {
+	for _, task := range tasks {
+		if task.Output.cached {
+			return true
+		}
+	}
+	return false
+}
+
+func hasBaselinedResults(tasks []*Task[ValidationOutput]) bool {
+	for _, task := range tasks {
+		if task.Output.baselined != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func hasFindings(tasks []*Task[ValidationOutput]) bool {
+	for _, task := range tasks {
+		if task.Output.findings != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Summarises the findings of a tool by severity, e.g. "2 error, 1 warning"
+func findingsSummary(output ValidationOutput) string {
+	if output.findings == nil {
+		return "-"
+	}
+
+	counts := map[string]int{}
+	for _, finding := range output.findings {
+		counts[finding.Severity]++
+	}
+
+	var summary []string
+	for _, severity := range []string{SeverityError, SeverityWarning, SeverityInfo} {
+		if counts[severity] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[severity], severity))
+		}
+	}
+	if len(summary) == 0 {
+		return "0"
+	}
+	return strings.Join(summary, ", ")
+}
+
+func formatFindings(findings []Finding) string {
+	lines := make([]string, len(findings))
+	for i, finding := range findings {
+		lines[i] = formatFinding(finding)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -1,12 +1,14 @@
 package prm_test
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
 	"github.com/puppetlabs/prm/internal/pkg/mock"
 	"github.com/puppetlabs/prm/pkg/prm"
 	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestPrm_Validate(t *testing.T) {
@@ -221,9 +223,89 @@ func TestPrm_Validate(t *testing.T) {
 				},
 			}
 
-			if err := p.Validate(tools, tt.args.workerCount, tt.args.outputSettings); err != nil && err.Error() != tt.args.expectedErrMsg {
+			if err := p.Validate(context.Background(), tools, tt.args.workerCount, tt.args.outputSettings); err != nil && err.Error() != tt.args.expectedErrMsg {
 				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func TestParallelCodeDirWriters(t *testing.T) {
+	readOnly := false
+	readOnlyTool := CreateToolInfo("lint", "puppetlabs", "0.1.0", nil)
+	readOnlyTool.Tool.Cfg.Common.NeedsWriteAccess = &readOnly
+	writerTool := CreateToolInfo("spec_cache", "puppetlabs", "0.1.0", nil)
+
+	tests := []struct {
+		name        string
+		toolsInfo   []prm.ToolInfo
+		workerCount int
+		want        []string
+	}{
+		{
+			name:        "Writers run serially",
+			toolsInfo:   []prm.ToolInfo{readOnlyTool, writerTool},
+			workerCount: 1,
+		},
+		{
+			name:        "Single writer on its own",
+			toolsInfo:   []prm.ToolInfo{writerTool},
+			workerCount: 5,
+		},
+		{
+			name:        "Only read-only tools run in parallel",
+			toolsInfo:   []prm.ToolInfo{readOnlyTool, readOnlyTool},
+			workerCount: 5,
+		},
+		{
+			name:        "Writer runs in parallel with other tools",
+			toolsInfo:   []prm.ToolInfo{readOnlyTool, writerTool},
+			workerCount: 5,
+			want:        []string{"puppetlabs/spec_cache"},
+		},
+		{
+			name:        "Writer runs in an earlier stage than the other tools",
+			toolsInfo:   []prm.ToolInfo{inStage(writerTool, 1), inStage(readOnlyTool, 2), inStage(readOnlyTool, 3)},
+			workerCount: 5,
+		},
+		{
+			name:        "Writer runs in the same stage as another tool",
+			toolsInfo:   []prm.ToolInfo{inStage(writerTool, 1), inStage(readOnlyTool, 1), inStage(readOnlyTool, 2)},
+			workerCount: 5,
+			want:        []string{"puppetlabs/spec_cache"},
+		},
+		{
+			name: "Writer is needed by the other tools, directly or through each other",
+			toolsInfo: func() []prm.ToolInfo {
+				first := CreateToolInfo("unit", "puppetlabs", "0.1.0", nil)
+				first.Tool.Cfg.Common.NeedsWriteAccess = &readOnly
+				first.Needs = []string{"puppetlabs/spec_cache"}
+				second := readOnlyTool
+				second.Needs = []string{"puppetlabs/unit"}
+				return []prm.ToolInfo{writerTool, first, second}
+			}(),
+			workerCount: 5,
+		},
+		{
+			name: "Writer runs in parallel with a tool that does not need it",
+			toolsInfo: func() []prm.ToolInfo {
+				first := CreateToolInfo("unit", "puppetlabs", "0.1.0", nil)
+				first.Tool.Cfg.Common.NeedsWriteAccess = &readOnly
+				first.Needs = []string{"puppetlabs/spec_cache"}
+				return []prm.ToolInfo{writerTool, first, readOnlyTool}
+			}(),
+			workerCount: 5,
+			want:        []string{"puppetlabs/spec_cache"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, prm.ParallelCodeDirWriters(tt.toolsInfo, tt.workerCount))
+		})
+	}
+}
+
+func inStage(info prm.ToolInfo, stage int) prm.ToolInfo {
+	info.Stage = stage
+	return info
+}
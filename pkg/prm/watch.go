@@ -0,0 +1,222 @@
+package prm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+)
+
+// The quiet period after a change before validation is re-run,
+// so that saving several files re-runs it once
+const DefaultWatchDebounce = 500 * time.Millisecond
+
+// Watch validates with the tools, then watches the codedir and re-validates
+// with the tools whose file patterns match the paths that changed, until the
+// context is cancelled. The results of every tool are redrawn after each run.
+func (p *Prm) Watch(ctx context.Context, toolsInfo []ToolInfo, workerCount int, settings OutputSettings, debounce time.Duration) error {
+	if status := p.Backend.Status(); !status.IsAvailable {
+		return p.errBackendNotRunning()
+	}
+	if len(toolsInfo) == 0 {
+		return fmt.Errorf("no tools provided for validation")
+	}
+	toolLogOutputPaths = make(map[string]string)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("unable to watch %s: %s", p.CodeDir, err)
+	}
+	defer watcher.Close() //nolint:errcheck
+
+	rules := p.readIgnoreRules()
+	if err := p.watchDirs(watcher, p.CodeDir, rules); err != nil {
+		return err
+	}
+
+	showPuppetVersion := isPuppetMatrix(toolsInfo)
+	results, err := p.runTasks(ctx, toolsInfo, workerCount, settings)
+	if err != nil {
+		return err
+	}
+	p.redrawResults(results, results, settings, showPuppetVersion)
+	settings.WriteBaseline = false
+	p.reuseImages()
+
+	changed := map[string]bool{}
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-watcher.Errors:
+			log.Warn().Msgf("Error watching %s: %s", p.CodeDir, err)
+		case event := <-watcher.Events:
+			relPath, ok := p.watchedPath(event.Name, rules)
+			if !ok {
+				continue
+			}
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := p.watchDirs(watcher, event.Name, rules); err != nil {
+						log.Warn().Msgf("Unable to watch %s: %s", event.Name, err)
+					}
+				}
+			}
+			changed[relPath] = true
+			timer.Reset(debounce)
+		case <-timer.C:
+			files := make([]string, 0, len(changed))
+			for file := range changed {
+				files = append(files, file)
+			}
+			sort.Strings(files)
+			changed = map[string]bool{}
+
+			rerun := SelectToolsForChanges(toolsInfo, files)
+			if len(rerun) == 0 {
+				log.Debug().Msgf("No tools match the changed files: %s", strings.Join(files, ", "))
+				continue
+			}
+			log.Info().Msgf("Re-validating after changes to %s", strings.Join(files, ", "))
+
+			tasks, err := p.runTasks(ctx, rerun, workerCount, settings)
+			if err != nil {
+				return err
+			}
+			results = mergeResults(results, tasks)
+			p.redrawResults(results, tasks, settings, showPuppetVersion)
+		}
+	}
+}
+
+// Writes the logs of the tools that just ran, then redraws the results of every tool
+func (p *Prm) redrawResults(results []*Task[ValidationOutput], ran []*Task[ValidationOutput], settings OutputSettings, showPuppetVersion bool) {
+	fmt.Print("\033[H\033[2J")
+	if err := p.writeOutputLogs(ran, settings); err != nil {
+		log.Error().Msgf("Unable to write the validation logs: %s", err)
+	}
+	if err := p.summariseResults(results, settings, showPuppetVersion); err != nil {
+		log.Error().Msg(err.Error())
+	}
+	log.Info().Msgf("Watching %s for changes. Press Ctrl+C to stop", p.CodeDir)
+}
+
+// Replaces the results of the tools that ran again, keeping the order of the first run
+func mergeResults(results []*Task[ValidationOutput], tasks []*Task[ValidationOutput]) []*Task[ValidationOutput] {
+	latest := map[string]*Task[ValidationOutput]{}
+	for _, task := range tasks {
+		latest[task.Name] = task
+	}
+	merged := make([]*Task[ValidationOutput], len(results))
+	for i, task := range results {
+		if rerun, ok := latest[task.Name]; ok {
+			task = rerun
+		}
+		merged[i] = task
+	}
+	return merged
+}
+
+// Images are built for the first run only; re-runs reuse them
+func (p *Prm) reuseImages() {
+	switch backend := p.Backend.(type) {
+	case *Docker:
+		backend.AlwaysBuild = false
+	case *Podman:
+		backend.AlwaysBuild = false
+	}
+}
+
+// Watches the directory and every directory below it that is not ignored
+func (p *Prm) watchDirs(watcher *fsnotify.Watcher, dir string, rules []ignoreRule) error {
+	return p.AFS.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return err
+		}
+		if file != p.CodeDir {
+			if _, ok := p.watchedPath(file, rules); !ok {
+				return filepath.SkipDir
+			}
+		}
+		return watcher.Add(file)
+	})
+}
+
+// Returns the path relative to the codedir, unless it is ignored
+// or is PRM's own output, which would otherwise re-trigger validation
+func (p *Prm) watchedPath(file string, rules []ignoreRule) (string, bool) {
+	if p.CacheDir != "" {
+		if rel, err := filepath.Rel(p.CacheDir, file); err == nil && !strings.HasPrefix(rel, "..") {
+			return "", false
+		}
+	}
+	relPath, err := filepath.Rel(p.CodeDir, file)
+	if err != nil || strings.HasPrefix(relPath, "..") {
+		return "", false
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	parts := strings.Split(relPath, "/")
+	for i := 1; i < len(parts); i++ {
+		if isIgnored(rules, strings.Join(parts[:i], "/"), true) {
+			return "", false
+		}
+	}
+	info, err := os.Stat(file)
+	isDir := err == nil && info.IsDir()
+	if isIgnored(rules, relPath, isDir) || isPrmStateFile(relPath) {
+		return "", false
+	}
+	return relPath, true
+}
+
+// SelectToolsForChanges returns the tools with a file pattern that matches one of the
+// changed files; tools that declare no file patterns are affected by any change
+func SelectToolsForChanges(toolsInfo []ToolInfo, files []string) []ToolInfo {
+	var selected []ToolInfo
+	for _, info := range toolsInfo {
+		patterns := info.Tool.Cfg.Common.FilePatterns
+		if len(patterns) == 0 {
+			selected = append(selected, info)
+			continue
+		}
+	files:
+		for _, file := range files {
+			for _, pattern := range patterns {
+				if matchesFilePattern(pattern, file) {
+					selected = append(selected, info)
+					break files
+				}
+			}
+		}
+	}
+	return selected
+}
+
+// Matches a file relative to the codedir against a glob. Patterns without a
+// slash match the file name; "**/" matches any number of leading directories.
+func matchesFilePattern(pattern string, file string) bool {
+	if rest := strings.TrimPrefix(pattern, "**/"); rest != pattern {
+		parts := strings.Split(file, "/")
+		for i := range parts {
+			if matched, _ := path.Match(rest, strings.Join(parts[i:], "/")); matched {
+				return true
+			}
+		}
+		return false
+	}
+	if !strings.Contains(pattern, "/") {
+		file = path.Base(file)
+	}
+	matched, _ := path.Match(pattern, file)
+	return matched
+}
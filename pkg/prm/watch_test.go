@@ -0,0 +1,65 @@
+package prm_test
+
+import (
+	"testing"
+
+	"github.com/puppetlabs/prm/pkg/prm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectToolsForChanges(t *testing.T) {
+	withPatterns := func(id string, patterns ...string) prm.ToolInfo {
+		info := CreateToolInfo(id, "puppetlabs", "0.1.0", nil)
+		info.Tool.Cfg.Common.FilePatterns = patterns
+		return info
+	}
+	toolsInfo := []prm.ToolInfo{
+		withPatterns("lint", "**/*.pp"),
+		withPatterns("epp", "templates/*.epp"),
+		withPatterns("metadata", "metadata.json"),
+		withPatterns("rubocop", "*.rb", "Gemfile"),
+		withPatterns("rspec"),
+	}
+
+	tests := []struct {
+		name  string
+		files []string
+		want  []string
+	}{
+		{
+			name:  "A nested manifest re-runs the tools matching any depth",
+			files: []string{"manifests/profile/web.pp"},
+			want:  []string{"lint", "rspec"},
+		},
+		{
+			name:  "A pattern with a directory matches the whole path",
+			files: []string{"templates/config.epp", "other/templates/config.epp"},
+			want:  []string{"epp", "rspec"},
+		},
+		{
+			name:  "A pattern without a directory matches the file name anywhere",
+			files: []string{"spec/spec_helper.rb"},
+			want:  []string{"rubocop", "rspec"},
+		},
+		{
+			name:  "Each tool is selected once",
+			files: []string{"metadata.json", "manifests/init.pp", "Gemfile", "lib/fact.rb"},
+			want:  []string{"lint", "metadata", "rubocop", "rspec"},
+		},
+		{
+			name:  "Tools without patterns re-run on any change",
+			files: []string{"README.md"},
+			want:  []string{"rspec"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, info := range prm.SelectToolsForChanges(toolsInfo, tt.files) {
+				got = append(got, info.Tool.Cfg.Plugin.Id)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -1,15 +1,25 @@
 package prm
 
 import (
+	"context"
 	"sync"
+	"time"
 )
 
 // Worker pool implementation adapted from https://brandur.org/go-worker-pool
 
 // Pool is a worker group that runs a number of tasks at a
-// configured concurrency.
+// configured concurrency. A task only starts once the tasks
+// it needs have finished.
 type Pool[T any] struct {
 	Tasks []*Task[T]
+	// When set, the remaining tasks are cancelled as soon as
+	// the output of a task satisfies it
+	CancelWhen func(output T) bool
+	// When both are set, a task is not run when the output of one of
+	// its needs satisfies SkipWhen; its output is given by Skip
+	SkipWhen func(output T) bool
+	Skip     func(task *Task[T], need *Task[T]) T
 
 	concurrency int
 	tasksChan   chan *Task[T]
@@ -29,15 +39,48 @@ func CreateWorkerPool[T any](tasks []*Task[T], workerCount int) *Pool[T] {
 }
 
 // Run runs all work within the pool and blocks until it's
-// finished.
-func (p *Pool[T]) Run() {
+// finished. Tasks are cancelled through their context when ctx is
+// done, or when a task's output satisfies CancelWhen.
+func (p *Pool[T]) Run(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	finished := make(chan *Task[T])
 	for i := 0; i < p.concurrency; i++ {
-		go p.work()
+		go p.work(ctx, cancel, finished)
 	}
 
-	for _, task := range p.Tasks {
-		p.wg.Add(1)
-		p.tasksChan <- task
+	done := make(map[*Task[T]]bool)
+	pending := append([]*Task[T]{}, p.Tasks...)
+	var ready []*Task[T]
+	running := 0
+	for {
+		// Scheduling can skip the last of the tasks, so check for them after it
+		pending, ready = p.schedule(pending, ready, done)
+		if len(pending) == 0 && len(ready) == 0 && running == 0 {
+			break
+		}
+		if len(ready) == 0 && running == 0 && len(pending) > 0 {
+			// The remaining tasks need each other, so run them in order rather than never start
+			ready = append(ready, pending...)
+			pending = nil
+			continue
+		}
+
+		var next chan *Task[T]
+		var task *Task[T]
+		if len(ready) > 0 {
+			next = p.tasksChan
+			task = ready[0]
+		}
+		select {
+		case next <- task:
+			ready = ready[1:]
+			running++
+		case task := <-finished:
+			done[task] = true
+			running--
+		}
 	}
 
 	// all workers return
@@ -46,10 +89,52 @@ func (p *Pool[T]) Run() {
 	p.wg.Wait()
 }
 
+// Moves the pending tasks whose needs have finished to the ready tasks,
+// in the order they were given, or skips them when a need failed
+func (p *Pool[T]) schedule(pending []*Task[T], ready []*Task[T], done map[*Task[T]]bool) ([]*Task[T], []*Task[T]) {
+	for progress := true; progress; {
+		progress = false
+		var waiting []*Task[T]
+		for _, task := range pending {
+			if need := p.skippingNeed(task, done); need != nil {
+				task.Output = p.Skip(task, need)
+				done[task] = true
+				progress = true
+				continue
+			}
+			if !task.needsDone(done) {
+				waiting = append(waiting, task)
+				continue
+			}
+			ready = append(ready, task)
+		}
+		pending = waiting
+	}
+	return pending, ready
+}
+
+// Returns the finished need of the task that means it is skipped, if any
+func (p *Pool[T]) skippingNeed(task *Task[T], done map[*Task[T]]bool) *Task[T] {
+	if p.SkipWhen == nil || p.Skip == nil {
+		return nil
+	}
+	for _, need := range task.Needs {
+		if done[need] && p.SkipWhen(need.Output) {
+			return need
+		}
+	}
+	return nil
+}
+
 // The work loop for any single goroutine.
-func (p *Pool[T]) work() {
+func (p *Pool[T]) work(ctx context.Context, cancel context.CancelFunc, finished chan<- *Task[T]) {
 	for task := range p.tasksChan {
-		task.Run(&p.wg)
+		p.wg.Add(1)
+		task.Run(ctx, &p.wg)
+		if p.CancelWhen != nil && p.CancelWhen(task.Output) {
+			cancel()
+		}
+		finished <- task
 	}
 }
 
@@ -61,18 +146,30 @@ type Task[T any] struct {
 	// for the pool that holds it.
 	Name   string
 	Output T
+	// The tasks of the pool that must finish before this one starts
+	Needs []*Task[T]
 
-	f func() T
+	f func(ctx context.Context) T
 }
 
 // Run runs a Task and does appropriate accounting via a
-// given sync.WorkGroup.
-func (t *Task[T]) Run(wg *sync.WaitGroup) {
-	t.Output = t.f()
+// given sync.WorkGroup. Tasks run after ctx is done are expected
+// to return promptly with an output that says they were cancelled.
+func (t *Task[T]) Run(ctx context.Context, wg *sync.WaitGroup) {
+	t.Output = t.f(ctx)
 	wg.Done()
 }
 
-func CreateTask[T any](name string, f func() T, output T) *Task[T] {
+func (t *Task[T]) needsDone(done map[*Task[T]]bool) bool {
+	for _, need := range t.Needs {
+		if !done[need] {
+			return false
+		}
+	}
+	return true
+}
+
+func CreateTask[T any](name string, f func(ctx context.Context) T, output T) *Task[T] {
 	return &Task[T]{
 		Name:   name,
 		Output: output,
@@ -84,4 +181,19 @@ type ValidationOutput struct {
 	err      error
 	exitCode ValidateExitCode
 	stdout   string
+	stderr   string
+	// The code the tool's process exited with, or NoExitCode if it did not run to completion
+	toolExitCode int
+	duration     time.Duration
+	findings     []Finding // only set for tools that declare an output_mode
+	// The Puppet version the tool was run against
+	puppetVersion string
+	// Whether the output was read from the result cache rather than running the tool
+	cached bool
+	// The number of findings suppressed by the baseline, or -1 if it suppressed the whole output
+	baselined int
+	// Whether the tool was stopped, or never started, because validation was cancelled
+	cancelled bool
+	// Why the tool was not run, when a tool it needs did not pass
+	skipped string
 }
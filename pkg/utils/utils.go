@@ -16,7 +16,18 @@ func (u *Utils) SetAndWriteConfig(k, v string) (err error) {
 
 	viper.Set(k, v)
 
-	if err = viper.WriteConfig(); err != nil {
+	// Write through a separate instance holding only the global config file,
+	// so settings merged in from project config files are not written to it
+	global := viper.New()
+	global.SetConfigFile(viper.ConfigFileUsed())
+	global.SetConfigType("yaml")
+	if err = global.ReadInConfig(); err != nil {
+		log.Error().Msgf("could not read config from %s: %s", viper.ConfigFileUsed(), err)
+		return err
+	}
+	global.Set(k, v)
+
+	if err = global.WriteConfig(); err != nil {
 		log.Error().Msgf("could not write config to %s: %s", viper.ConfigFileUsed(), err)
 	}
 	return err